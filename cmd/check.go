@@ -1,49 +1,108 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/VKCOM/noverify/src/cmd"
 	"github.com/VKCOM/noverify/src/linter"
+	"github.com/vkcom/nocolor/internal/baseline"
 	"github.com/vkcom/nocolor/internal/callgraph"
+	"github.com/vkcom/nocolor/internal/customedges"
+	"github.com/vkcom/nocolor/internal/diff"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/entrypoints"
+	"github.com/vkcom/nocolor/internal/includes"
 	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pathutil"
 	"github.com/vkcom/nocolor/internal/pipes"
 	"github.com/vkcom/nocolor/internal/symbols"
 	"github.com/vkcom/nocolor/internal/walkers"
 )
 
+// collectFlags are the flags of collecting the call graph,
+// which are the same for all commands that analyze the project.
+type collectFlags struct {
+	PaletteSrc   string
+	EdgesSrc     string
+	ExcludeEdges string
+	Dependencies bool
+	ColorTag     string
+
+	IncludeRoots  string
+	PathConstants string
+	ComposerSrc   string
+}
+
 type extraCheckFlags struct {
-	PaletteSrc string
-	ColorTag   string
-	Output     string
+	collectFlags
+
+	Output    string
+	Format    string
+	SourceURL string
+
+	BaselineSrc      string
+	GenerateBaseline string
+	DiffSrc          string
+
+	PreciseCycles  bool
+	EntryPointsSrc string
+	MaxReports     int
 }
 
 // Check is the function that starts the analysis of the project.
 func Check(ctx *cmd.AppContext, globalContext *walkers.GlobalContext) (status int, err error) {
 	flags := ctx.CustomFlags.(*extraCheckFlags)
 
-	pal, err := palette.OpenPaletteFromFile(flags.PaletteSrc)
+	if flags.Format == "" {
+		// For compatibility, the reports are written to the output
+		// file in the JSON format of the previous releases by default.
+		flags.Format = formatText
+		if flags.Output != "" {
+			flags.Format = formatJSONLegacy
+		}
+	}
+	if !formatExists(flags.Format) {
+		return 1, fmt.Errorf("invalid value of the --format flag: unknown format '%s', expected one of: %s", flags.Format, strings.Join(formats, ", "))
+	}
+
+	pal, edges, err := prepareCollecting(ctx, globalContext, &flags.collectFlags)
 	if err != nil {
 		return 1, err
 	}
 
-	// Registering custom walkers for collecting the call graph.
-	walkers.Register(ctx.MainConfig.LinterConfig, globalContext, pal, flags.ColorTag)
+	var entryPoints *entrypoints.Config
+	if flags.EntryPointsSrc != "" {
+		entryPoints, err = entrypoints.OpenConfigFromFile(flags.EntryPointsSrc, pal)
+		if err != nil {
+			return 1, err
+		}
+	}
 
-	// If there are no arguments, then we interpret this as
-	// an analysis of the current directory.
-	if len(ctx.ParsedArgs) == 0 {
-		ctx.ParsedArgs = append(ctx.ParsedArgs, "./")
+	var accepted *baseline.Baseline
+	if flags.BaselineSrc != "" {
+		accepted, err = baseline.OpenFromFile(flags.BaselineSrc)
+		if err != nil {
+			return 1, err
+		}
+	}
+
+	var changes *diff.Changes
+	if flags.DiffSrc != "" {
+		changes, err = diff.OpenFromFile(flags.DiffSrc)
+		if err != nil {
+			return 1, err
+		}
 	}
 
 	// The main function for analyzing in NoVerify,
 	// in it, we collect all the functions of the project.
 	_, err = cmd.Check(ctx)
 	if len(LinterReports) != 0 {
-		HandleShowLinterReports(ctx, LinterReports)
+		HandleShowLinterReports(ctx, pal, globalContext.Functions, LinterReports)
 		return 2, err
 	}
 
@@ -55,79 +114,340 @@ func Check(ctx *cmd.AppContext, globalContext *walkers.GlobalContext) (status in
 		return status, nil
 	}
 
+	// Edges that cannot be resolved statically
+	// must be added before checking colors.
+	if edges != nil {
+		pipes.AddCustomEdges(globalContext.Functions, edges)
+	}
+
 	// Function that starts checking colors.
-	reports := HandleFunctions(ctx, globalContext.Functions, pal)
+	reports := HandleFunctions(ctx, globalContext.Functions, pal, CheckOptions{
+		PreciseCycles: flags.PreciseCycles,
+		EntryPoints:   entryPoints,
+	})
 
+	handleShowUnresolvedIncludes(globalContext.UnresolvedIncludes.Sorted())
+	handleShowUnusedSuppressions(pipes.UnusedSuppressions(globalContext.Functions, globalContext.Classes))
+	if entryPoints != nil {
+		handleShowUnreachableFunctions(pipes.FindUnreachableFunctions(globalContext.Functions, entryPoints))
+	}
+
+	if flags.GenerateBaseline != "" {
+		generated := baseline.New(reports)
+		if err := generated.WriteToFile(flags.GenerateBaseline); err != nil {
+			return 1, err
+		}
+
+		log.Printf("Baseline with %d violations is written to the '%s' file\n", len(generated.Entries), flags.GenerateBaseline)
+		return 0, nil
+	}
+
+	var suppressed int
+	if accepted != nil {
+		var fixed []*baseline.Entry
+		reports, suppressed, fixed = accepted.Filter(reports)
+		handleShowFixedBaselineEntries(fixed, flags.BaselineSrc)
+	}
+
+	// The whole project is checked, so that the chains through
+	// the unchanged files are found, and only then filtered.
+	if changes != nil {
+		touched := changes.Filter(reports)
+		if skipped := len(reports) - len(touched); skipped != 0 {
+			log.Printf("%d violations do not touch the changed lines of the diff and are not shown\n", skipped)
+		}
+		reports = touched
+	}
+
+	if flags.MaxReports > 0 && len(reports) > flags.MaxReports {
+		log.Printf("Found %d violations, only the first %d of them are shown, use the --max-reports option to show more", len(reports), flags.MaxReports)
+		reports = reports[:flags.MaxReports]
+	}
+
+	HandleShowColorReports(ctx, pal, globalContext.Functions, reports, suppressed)
 	if len(reports) != 0 {
-		HandleShowColorReports(ctx, reports)
 		return 2, nil
 	}
 
-	log.Printf("No critical issues found. Your code is perfect.")
 	return 0, nil
 }
 
+// prepareCollecting opens the palette and the custom edges and
+// registers the walkers that collect the call graph of the project.
+func prepareCollecting(ctx *cmd.AppContext, globalContext *walkers.GlobalContext, flags *collectFlags) (*palette.Palette, *customedges.Config, error) {
+	pal, err := palette.OpenPaletteFromFile(flags.PaletteSrc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	excludedEdges, err := edgekind.Parse(flags.ExcludeEdges)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value of the --exclude-edges flag: %v", err)
+	}
+	pal.ExcludeEdges(excludedEdges)
+
+	var edges *customedges.Config
+	if flags.EdgesSrc != "" {
+		edges, err = customedges.OpenConfigFromFile(flags.EdgesSrc)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	globalContext.Dependencies = flags.Dependencies
+
+	globalContext.Includes.Roots = includes.ParseRoots(flags.IncludeRoots)
+	globalContext.Includes.Constants, err = includes.ParseConstants(flags.PathConstants)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value of the --path-constants flag: %v", err)
+	}
+	if flags.ComposerSrc != "" {
+		globalContext.Includes.Autoload, err = includes.OpenComposerFromFile(flags.ComposerSrc)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Registering custom walkers for collecting the call graph.
+	walkers.Register(ctx.MainConfig.LinterConfig, globalContext, pal, flags.ColorTag)
+
+	// If there are no arguments, then we interpret this as
+	// an analysis of the current directory.
+	if len(ctx.ParsedArgs) == 0 {
+		ctx.ParsedArgs = append(ctx.ParsedArgs, "./")
+	}
+
+	return pal, edges, nil
+}
+
+// CheckOptions are the options of checking colors.
+type CheckOptions struct {
+	// PreciseCycles enables the exploration of the real
+	// paths inside recursive components of the call graph.
+	PreciseCycles bool
+
+	// EntryPoints are the functions from which the chains
+	// are searched, if nil, the roots are found heuristically.
+	EntryPoints *entrypoints.Config
+}
+
 // HandleFunctions is a function that starts checking colors.
-func HandleFunctions(ctx *cmd.AppContext, funcs *symbols.Functions, palette *palette.Palette) []*pipes.ColorReport {
-	workers := ctx.ParsedFlags.MaxConcurrency
+//
+// The violations suppressed by the '@nocolor-ignore' tags are not returned.
+//
+// Rulesets that ignore different kinds of edges are checked on different
+// graphs, so the check is started separately for each group of them.
+func HandleFunctions(ctx *cmd.AppContext, funcs *symbols.Functions, palette *palette.Palette, opts CheckOptions) []*pipes.ColorReport {
+	var reports []*pipes.ColorReport
+
+	for _, pal := range palette.SplitByExcludedEdges() {
+		reports = append(reports, handleFunctionsWithPalette(ctx, funcs, pal, opts)...)
+	}
+
+	pipes.SortReports(reports)
+
+	return pipes.SuppressReports(reports)
+}
+
+func handleFunctionsWithPalette(ctx *cmd.AppContext, funcs *symbols.Functions, palette *palette.Palette, opts CheckOptions) []*pipes.ColorReport {
+	// The graphs and the roots of the large graphs share the workers.
+	workers := pipes.NewWorkers(ctx.ParsedFlags.MaxConcurrency)
 	reportsCh := make(chan []*pipes.ColorReport, 10)
 	graphsCh := make(chan *callgraph.Graph, 10)
 
-	nodes := pipes.FunctionsToNodes(funcs)
+	// All rulesets of the palette ignore the same kinds of edges.
+	nodes := pipes.FunctionsToNodes(funcs, palette.Rulesets[0].ExcludedEdges)
 	graphs := pipes.NodesToGraphs(nodes)
 
 	pipes.WriteGraphsAsync(graphs, graphsCh)
 
 	pipes.Async(workers, graphsCh, reportsCh, func(graph *callgraph.Graph) []*pipes.ColorReport {
 		pipes.EraseNodesWithRemoverColor(graph)
-		pipes.CalcNextWithColor(graph)
 
-		reports := pipes.CheckColorsInGraph(graph, palette)
+		var reports []*pipes.ColorReport
+		for _, scoped := range pipes.SplitByScopedRemovers(graph, palette) {
+			pipes.CalcNextWithColor(scoped.Graph, opts.PreciseCycles)
+
+			var roots callgraph.Nodes
+			if opts.EntryPoints != nil {
+				roots = pipes.FindEntryNodes(scoped.Graph, opts.EntryPoints)
+			} else {
+				roots = pipes.FindRootNodes(scoped.Graph)
+			}
+
+			reports = append(reports, pipes.CheckColorsInGraph(scoped.Graph, scoped.Palette, roots, workers)...)
+		}
 		return reports
 	})
 
 	return pipes.ReadReportsSync(reportsCh)
 }
 
-func HandleShowColorReports(ctx *cmd.AppContext, reports []*pipes.ColorReport) {
+// maxShownUnresolvedIncludes is the maximum number of
+// unresolved includes listed in the summary.
+const maxShownUnresolvedIncludes = 20
+
+// handleShowUnresolvedIncludes prints the summary of the includes that
+// could not be resolved, since the edges through them are missing.
+func handleShowUnresolvedIncludes(unresolved []includes.Unresolved) {
+	if len(unresolved) == 0 {
+		return
+	}
+
+	log.Printf("Could not resolve %d includes, calls through them are not checked:", len(unresolved))
+	for i, include := range unresolved {
+		if i == maxShownUnresolvedIncludes {
+			log.Printf("  ... and %d more", len(unresolved)-maxShownUnresolvedIncludes)
+			break
+		}
+
+		log.Printf("  %s:%d  %s", pathutil.Relative(include.Filename), include.Line, include.Expr)
+	}
+	log.Printf("Use the --include-roots, --path-constants and --composer options to resolve them")
+}
+
+// maxShownUnusedSuppressions is the maximum number
+// of unused '@nocolor-ignore' tags listed in the summary.
+const maxShownUnusedSuppressions = 20
+
+// handleShowUnusedSuppressions prints the summary of the '@nocolor-ignore'
+// tags that suppress nothing, so that the stale ones are removed.
+func handleShowUnusedSuppressions(unused []*symbols.Suppression) {
+	if len(unused) == 0 {
+		return
+	}
+
+	log.Printf("%d '@%s' tags suppress nothing:", len(unused), symbols.SuppressionTag)
+	for i, suppression := range unused {
+		if i == maxShownUnusedSuppressions {
+			log.Printf("  ... and %d more", len(unused)-maxShownUnusedSuppressions)
+			break
+		}
+
+		log.Printf("  %s:%d  %s  '%s'", pathutil.Relative(suppression.Pos.Filename), suppression.Pos.Line, suppression.Place, suppression.Target)
+	}
+	log.Printf("Remove them, if the violations are fixed")
+}
+
+// maxShownUnreachableFunctions is the maximum number of
+// unreachable colored functions listed in the summary.
+const maxShownUnreachableFunctions = 20
+
+// handleShowUnreachableFunctions prints the summary of the colored functions
+// that are not reachable from any entry point, since they are not checked.
+func handleShowUnreachableFunctions(unreachable []*symbols.Function) {
+	if len(unreachable) == 0 {
+		return
+	}
+
+	log.Printf("%d colored functions are not reachable from any entry point, calls from them are not checked:", len(unreachable))
+	for i, fun := range unreachable {
+		if i == maxShownUnreachableFunctions {
+			log.Printf("  ... and %d more", len(unreachable)-maxShownUnreachableFunctions)
+			break
+		}
+
+		log.Printf("  %s", fun.HumanReadableName())
+	}
+	log.Printf("Add them or their callers to the --entry-points file, if they are called")
+}
+
+// maxShownFixedBaselineEntries is the maximum number
+// of fixed violations of the baseline listed in the summary.
+const maxShownFixedBaselineEntries = 20
+
+// handleShowFixedBaselineEntries prints the summary of the violations
+// of the baseline that no longer occur, so that the file can be pruned.
+func handleShowFixedBaselineEntries(fixed []*baseline.Entry, path string) {
+	if len(fixed) == 0 {
+		return
+	}
+
+	log.Printf("%d violations of the baseline are fixed:", len(fixed))
+	for i, entry := range fixed {
+		if i == maxShownFixedBaselineEntries {
+			log.Printf("  ... and %d more", len(fixed)-maxShownFixedBaselineEntries)
+			break
+		}
+
+		log.Printf("  %s  %s: %s -> %s", entry.Fingerprint, entry.Rule, entry.First, entry.Last)
+	}
+	log.Printf("Remove them from the '%s' file or regenerate it with the --generate-baseline option", path)
+}
+
+func HandleShowColorReports(ctx *cmd.AppContext, pal *palette.Palette, funcs *symbols.Functions, reports []*pipes.ColorReport, suppressed int) {
 	generalReports := make([]*pipes.GeneralReport, 0, len(reports))
 	for _, report := range reports {
 		generalReports = append(generalReports, pipes.NewGeneralReportFromColorReport(report))
 	}
-	handleShowReports(ctx, generalReports)
+	handleShowReports(ctx, pal, funcs, generalReports, suppressed)
 }
 
-func HandleShowLinterReports(ctx *cmd.AppContext, reports []*linter.Report) {
+func HandleShowLinterReports(ctx *cmd.AppContext, pal *palette.Palette, funcs *symbols.Functions, reports []*linter.Report) {
 	generalReports := make([]*pipes.GeneralReport, 0, len(reports))
 	for _, report := range reports {
 		generalReports = append(generalReports, pipes.NewGeneralReportFromLinterReport(report))
 	}
-	handleShowReports(ctx, generalReports)
+	handleShowReports(ctx, pal, funcs, generalReports, 0)
 }
 
-func handleShowReports(ctx *cmd.AppContext, reports []*pipes.GeneralReport) {
+// handleShowReports writes the reports in the format from the --format
+// flag to the file from the --output flag, or to the standard output.
+//
+// In the machine-readable formats, the file is written even if there
+// are no reports, so that the CI always has a result to process.
+//
+// The suppressed is the number of the reports suppressed by the baseline.
+func handleShowReports(ctx *cmd.AppContext, pal *palette.Palette, funcs *symbols.Functions, reports []*pipes.GeneralReport, suppressed int) {
 	flags := ctx.CustomFlags.(*extraCheckFlags)
-	toJSON := flags.Output != ""
-	if toJSON {
-		fileName := flags.Output
-		data, err := json.Marshal(reports)
-		if err != nil {
-			log.Printf("Error marshal json: %v", err)
+
+	if flags.Format == formatText && flags.Output == "" {
+		if len(reports) == 0 && suppressed == 0 {
+			log.Printf("No critical issues found. Your code is perfect.")
 			return
 		}
-		err = ioutil.WriteFile(fileName, data, 0644)
-		if err != nil {
-			log.Printf("Error write json: %v", err)
-			return
+
+		for _, report := range reports {
+			fmt.Println(report)
 		}
+		logReportsSummary(len(reports), suppressed)
+		return
+	}
 
-		log.Printf("Found %d critical reports\n", len(reports))
-		log.Printf("Reports are written to the '%s' file\n", fileName)
+	data, err := formatReports(flags, pal, funcs, reports)
+	if err != nil {
+		log.Printf("Error format reports: %v", err)
 		return
 	}
 
-	for _, report := range reports {
-		fmt.Println(report)
+	if flags.Output == "" {
+		os.Stdout.Write(data)
+		logReportsSummary(len(reports), suppressed)
+		return
 	}
-	log.Printf("Found %d critical reports\n", len(reports))
+
+	err = ioutil.WriteFile(flags.Output, data, 0644)
+	if err != nil {
+		log.Printf("Error write reports: %v", err)
+		return
+	}
+
+	logReportsSummary(len(reports), suppressed)
+	log.Printf("Reports are written to the '%s' file\n", flags.Output)
+}
+
+// logReportsSummary prints the number of the reports
+// and the number of the reports suppressed by the baseline.
+func logReportsSummary(count, suppressed int) {
+	if suppressed == 0 {
+		log.Printf("Found %d critical reports\n", count)
+		return
+	}
+
+	if count == 0 {
+		log.Printf("No new critical issues found, %d reports are suppressed by the baseline\n", suppressed)
+		return
+	}
+
+	log.Printf("Found %d critical reports, %d more are suppressed by the baseline\n", count, suppressed)
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pipes"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// Formats of the reports for the --format flag.
+const (
+	formatText       = "text"
+	formatJSON       = "json"
+	formatJSONLegacy = "json-legacy"
+	formatSARIF      = "sarif"
+	formatCheckstyle = "checkstyle"
+	formatJUnit      = "junit"
+	formatHTML       = "html"
+	formatMarkdown   = "markdown"
+)
+
+var formats = []string{formatText, formatJSON, formatJSONLegacy, formatSARIF, formatCheckstyle, formatJUnit, formatHTML, formatMarkdown}
+
+func formatExists(format string) bool {
+	for _, name := range formats {
+		if name == format {
+			return true
+		}
+	}
+	return false
+}
+
+// formatReports returns the reports in the format from the --format flag.
+func formatReports(flags *extraCheckFlags, pal *palette.Palette, funcs *symbols.Functions, reports []*pipes.GeneralReport) ([]byte, error) {
+	switch format := flags.Format; format {
+	case formatText:
+		var buf bytes.Buffer
+		for _, report := range reports {
+			fmt.Fprintln(&buf, report)
+		}
+		return buf.Bytes(), nil
+
+	case formatJSON:
+		data, err := json.MarshalIndent(pipes.NewJSONReport(reports, pal, Version), "", "  ")
+		return append(data, '\n'), err
+
+	case formatJSONLegacy:
+		return json.Marshal(reports)
+
+	case formatSARIF:
+		data, err := json.MarshalIndent(pipes.NewSARIFLog(reports, pal, Version), "", "  ")
+		return append(data, '\n'), err
+
+	case formatCheckstyle:
+		return marshalXML(pipes.NewCheckstyleReport(reports))
+
+	case formatJUnit:
+		return marshalXML(pipes.NewJUnitReport(reports, pal))
+
+	case formatHTML:
+		var buf bytes.Buffer
+		err := pipes.WriteHTMLReport(&buf, reports, pal, funcs, pipes.HTMLOptions{
+			Version:   Version,
+			SourceURL: flags.SourceURL,
+		})
+		return buf.Bytes(), err
+
+	case formatMarkdown:
+		var buf bytes.Buffer
+		err := pipes.WriteMarkdownReport(&buf, reports, pal)
+		return buf.Bytes(), err
+	}
+
+	return nil, fmt.Errorf("unknown format '%s'", flags.Format)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte(xml.Header), data...), '\n'), nil
+}
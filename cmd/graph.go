@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/VKCOM/noverify/src/cmd"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/graphexport"
+	"github.com/vkcom/nocolor/internal/pipes"
+	"github.com/vkcom/nocolor/internal/walkers"
+)
+
+// Formats of the graph for the --format flag of the graph command.
+const (
+	graphFormatDOT     = "dot"
+	graphFormatGraphML = "graphml"
+	graphFormatJSON    = "json"
+)
+
+type extraGraphFlags struct {
+	collectFlags
+
+	Output string
+	Format string
+
+	Component           int
+	Function            string
+	Depth               int
+	ColoredOnly         bool
+	CollapseTransparent bool
+}
+
+// Graph is the function that exports the call graph of the project.
+func Graph(ctx *cmd.AppContext, globalContext *walkers.GlobalContext) (status int, err error) {
+	flags := ctx.CustomFlags.(*extraGraphFlags)
+
+	switch flags.Format {
+	case graphFormatDOT, graphFormatGraphML, graphFormatJSON:
+	default:
+		return 1, fmt.Errorf("invalid value of the --format flag: unknown format '%s', expected one of: %s", flags.Format, strings.Join([]string{graphFormatDOT, graphFormatGraphML, graphFormatJSON}, ", "))
+	}
+
+	pal, edges, err := prepareCollecting(ctx, globalContext, &flags.collectFlags)
+	if err != nil {
+		return 1, err
+	}
+
+	status, err = cmd.Check(ctx)
+	if len(LinterReports) != 0 {
+		for _, report := range LinterReports {
+			log.Println(pipes.NewGeneralReportFromLinterReport(report))
+		}
+		log.Printf("Found %d errors in the color tags, the graph is not exported", len(LinterReports))
+		return 2, err
+	}
+	if status != 0 {
+		return status, err
+	}
+
+	if edges != nil {
+		pipes.AddCustomEdges(globalContext.Functions, edges)
+	}
+
+	// The flag is already checked in prepareCollecting.
+	excludedEdges, _ := edgekind.Parse(flags.ExcludeEdges)
+
+	nodes := pipes.FunctionsToNodes(globalContext.Functions, excludedEdges)
+	graph, err := graphexport.Build(nodes, pal, graphexport.Options{
+		Component:           flags.Component,
+		Function:            flags.Function,
+		Depth:               flags.Depth,
+		ColoredOnly:         flags.ColoredOnly,
+		CollapseTransparent: flags.CollapseTransparent,
+	})
+	if err != nil {
+		return 1, err
+	}
+
+	var buf bytes.Buffer
+	switch flags.Format {
+	case graphFormatDOT:
+		err = graph.WriteDOT(&buf)
+	case graphFormatGraphML:
+		err = graph.WriteGraphML(&buf)
+	case graphFormatJSON:
+		err = graph.WriteJSON(&buf)
+	}
+	if err != nil {
+		return 1, err
+	}
+
+	if flags.Output == "" {
+		os.Stdout.Write(buf.Bytes())
+		return 0, nil
+	}
+
+	err = ioutil.WriteFile(flags.Output, buf.Bytes(), 0644)
+	if err != nil {
+		return 1, err
+	}
+
+	log.Printf("Graph with %d functions and %d calls is written to the '%s' file\n", len(graph.Nodes), len(graph.Edges), flags.Output)
+	return 0, nil
+}
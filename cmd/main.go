@@ -12,6 +12,7 @@ import (
 	"github.com/VKCOM/noverify/src/linter"
 
 	"github.com/vkcom/nocolor/internal/checkers"
+	"github.com/vkcom/nocolor/internal/pipes"
 	"github.com/vkcom/nocolor/internal/walkers"
 )
 
@@ -53,6 +54,66 @@ func DefaultCacheDir() string {
 	return filepath.Join(defaultCacheDir, "nocolor-cache")
 }
 
+// registerCollectFlags registers the flags of collecting the call graph,
+// which are the same for all commands that analyze the project.
+func registerCollectFlags(ctx *cmd.AppContext, fs *flag.FlagSet, flags *collectFlags) *cmd.FlagsGroups {
+	groups := cmd.NewFlagsGroups()
+
+	groups.AddGroup("Color")
+	groups.AddGroup("Language")
+	groups.AddGroup("Files")
+	groups.AddGroup("Includes")
+	groups.AddGroup("Additional")
+
+	// We don't need all the flags from NoVerify, so we only register some of them.
+	fs.IntVar(&ctx.ParsedFlags.MaxFileSize, "max-sum-filesize", 10*1024*1024, "Max total file size to be parsed concurrently in bytes (limits max memory consumption)")
+	fs.IntVar(&ctx.ParsedFlags.MaxConcurrency, "cores", runtime.NumCPU(), "Max number of cores to use")
+	fs.StringVar(&ctx.ParsedFlags.StubsDir, "stubs-dir", "", "Directory with custom phpstorm-stubs")
+	fs.StringVar(&ctx.ParsedFlags.CacheDir, "cache-dir", DefaultCacheDir(), "Directory for linter cache (greatly improves indexing speed)")
+	fs.BoolVar(&ctx.ParsedFlags.DisableCache, "disable-cache", false, "If set, cache is not used and cache-dir is ignored")
+
+	groups.Add("Additional", "cores")
+	groups.Add("Additional", "cache-dir")
+	groups.Add("Additional", "disable-cache")
+	groups.Add("Additional", "max-sum-filesize")
+	groups.Add("Additional", "stubs-dir")
+
+	fs.StringVar(&ctx.ParsedFlags.IndexOnlyFiles, "index-only-files", "", "Comma-separated list of paths to files, which should be indexed, but not analyzed")
+	fs.StringVar(&ctx.ParsedFlags.PhpExtensionsArg, "php-exts", "php,inc,php5,phtml", "List of PHP file extensions to be analyzed")
+
+	groups.Add("Files", "index-only-files")
+	groups.Add("Files", "php-exts")
+
+	fs.BoolVar(&ctx.ParsedFlags.PHP7, "php7", false, "Analyze as PHP 7")
+	groups.Add("Language", "php7")
+
+	// Some values need to be set manually.
+	ctx.ParsedFlags.AllowAll = true
+	ctx.ParsedFlags.ReportsCritical = cmd.AllNonNoticeChecks
+
+	fs.StringVar(&flags.PaletteSrc, "palette", "palette.yaml", "File with color palette")
+	fs.StringVar(&flags.ColorTag, "tag", "color", "The tag to be used to set the color in PHPDoc")
+	fs.StringVar(&flags.EdgesSrc, "edges", "", "File with custom edges for calls that cannot be resolved statically")
+	fs.StringVar(&flags.ExcludeEdges, "exclude-edges", "", "Comma-separated list of kinds of edges that are ignored when checking colors")
+	fs.BoolVar(&flags.Dependencies, "dependencies", false, "Also check the references to classes by type, for example, in type hints, extends and instanceof")
+
+	groups.Add("Color", "palette")
+	groups.Add("Color", "tag")
+	groups.Add("Color", "edges")
+	groups.Add("Color", "exclude-edges")
+	groups.Add("Color", "dependencies")
+
+	fs.StringVar(&flags.IncludeRoots, "include-roots", "", "Comma-separated list of directories where included files are searched, like the include_path option")
+	fs.StringVar(&flags.PathConstants, "path-constants", "", "Comma-separated list of constants with paths used in includes, in the 'NAME=path' format")
+	fs.StringVar(&flags.ComposerSrc, "composer", "", "Path to the composer.json file to resolve the files of autoloaded classes")
+
+	groups.Add("Includes", "include-roots")
+	groups.Add("Includes", "path-constants")
+	groups.Add("Includes", "composer")
+
+	return groups
+}
+
 // Main is the function that launches the program.
 func Main() {
 	config := linter.NewConfig("8.1")
@@ -83,6 +144,15 @@ func Main() {
 				},
 			})
 
+			app.Commands = append(app.Commands, &cmd.Command{
+				Name:        "report-schema",
+				Description: "The command to output the JSON Schema of the report in JSON format",
+				Action: func(ctx *cmd.AppContext) (int, error) {
+					os.Stdout.Write(pipes.JSONReportSchema)
+					return 0, nil
+				},
+			})
+
 			app.Commands = append(app.Commands, &cmd.Command{
 				Name:        "cache-clear",
 				Description: "The command to clear the cache",
@@ -112,46 +182,32 @@ func Main() {
 					flags := &extraCheckFlags{}
 
 					fs := flag.NewFlagSet("check", flag.ContinueOnError)
-					groups := cmd.NewFlagsGroups()
-
-					groups.AddGroup("Color")
-					groups.AddGroup("Language")
-					groups.AddGroup("Files")
-					groups.AddGroup("Additional")
-
-					// We don't need all the flags from NoVerify, so we only register some of them.
-					fs.IntVar(&ctx.ParsedFlags.MaxFileSize, "max-sum-filesize", 10*1024*1024, "Max total file size to be parsed concurrently in bytes (limits max memory consumption)")
-					fs.IntVar(&ctx.ParsedFlags.MaxConcurrency, "cores", runtime.NumCPU(), "Max number of cores to use")
-					fs.StringVar(&ctx.ParsedFlags.StubsDir, "stubs-dir", "", "Directory with custom phpstorm-stubs")
-					fs.StringVar(&ctx.ParsedFlags.CacheDir, "cache-dir", DefaultCacheDir(), "Directory for linter cache (greatly improves indexing speed)")
-					fs.BoolVar(&ctx.ParsedFlags.DisableCache, "disable-cache", false, "If set, cache is not used and cache-dir is ignored")
-
-					groups.Add("Additional", "cores")
-					groups.Add("Additional", "cache-dir")
-					groups.Add("Additional", "disable-cache")
-					groups.Add("Additional", "max-sum-filesize")
-					groups.Add("Additional", "stubs-dir")
-
-					fs.StringVar(&ctx.ParsedFlags.IndexOnlyFiles, "index-only-files", "", "Comma-separated list of paths to files, which should be indexed, but not analyzed")
-					fs.StringVar(&ctx.ParsedFlags.PhpExtensionsArg, "php-exts", "php,inc,php5,phtml", "List of PHP file extensions to be analyzed")
-					fs.StringVar(&flags.Output, "output", "", "Path to the file where the errors will be written in JSON format")
-
-					groups.Add("Files", "index-only-files")
-					groups.Add("Files", "php-exts")
+					groups := registerCollectFlags(ctx, fs, &flags.collectFlags)
+
+					fs.StringVar(&flags.Output, "output", "", "Path to the file where the errors will be written, in the json-legacy format if --format is not set")
+					fs.StringVar(&flags.Format, "format", "", "Format of the reports: text, json, json-legacy, sarif, checkstyle, junit, html or markdown, text by default, or json-legacy if --output is set")
+					fs.StringVar(&flags.SourceURL, "source-url", "", "Template of the links to the source code in the HTML report with {file} and {line} placeholders, like 'https://github.com/org/repo/blob/master/{file}#L{line}'")
+					fs.IntVar(&flags.MaxReports, "max-reports", 0, "Maximum number of reported violations, 0 means no limit")
+
 					groups.Add("Files", "output")
+					groups.Add("Files", "format")
+					groups.Add("Files", "source-url")
+					groups.Add("Files", "max-reports")
+
+					fs.StringVar(&flags.BaselineSrc, "baseline", "", "File with the accepted violations, which are not reported, generated by the --generate-baseline option")
+					fs.StringVar(&flags.GenerateBaseline, "generate-baseline", "", "Path to the file where all found violations are written as the baseline instead of reporting them")
 
-					fs.BoolVar(&ctx.ParsedFlags.PHP7, "php7", false, "Analyze as PHP 7")
-					groups.Add("Language", "php7")
+					groups.Add("Files", "baseline")
+					groups.Add("Files", "generate-baseline")
 
-					// Some values need to be set manually.
-					ctx.ParsedFlags.AllowAll = true
-					ctx.ParsedFlags.ReportsCritical = cmd.AllNonNoticeChecks
+					fs.StringVar(&flags.DiffSrc, "diff", "", "File with the unified diff, or '-' for the standard input, only the violations that touch its changed lines are reported")
+					groups.Add("Files", "diff")
 
-					fs.StringVar(&flags.PaletteSrc, "palette", "palette.yaml", "File with color palette")
-					fs.StringVar(&flags.ColorTag, "tag", "color", "The tag to be used to set the color in PHPDoc")
+					fs.BoolVar(&flags.PreciseCycles, "precise-cycles", false, "Explore the real paths inside recursive components of the call graph, slower but more precise")
+					fs.StringVar(&flags.EntryPointsSrc, "entry-points", "", "File with entry points, the call chains are searched only from them")
 
-					groups.Add("Color", "palette")
-					groups.Add("Color", "tag")
+					groups.Add("Color", "precise-cycles")
+					groups.Add("Color", "entry-points")
 
 					ctx.CustomFlags = flags
 					return fs, groups
@@ -160,6 +216,48 @@ func Main() {
 					return Check(ctx, context)
 				},
 			})
+			app.Commands = append(app.Commands, &cmd.Command{
+				Name:        "graph",
+				Description: "The command to export the colored call graph",
+				Arguments: []*cmd.Argument{
+					{
+						Name:        "targets",
+						Description: "Folders or files for analysis",
+					},
+				},
+				RegisterFlags: func(ctx *cmd.AppContext) (*flag.FlagSet, *cmd.FlagsGroups) {
+					flags := &extraGraphFlags{}
+
+					fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+					groups := registerCollectFlags(ctx, fs, &flags.collectFlags)
+
+					fs.StringVar(&flags.Output, "output", "", "Path to the file where the graph will be written, the standard output by default")
+					fs.StringVar(&flags.Format, "format", graphFormatDOT, "Format of the graph: dot, graphml or json")
+
+					groups.Add("Files", "output")
+					groups.Add("Files", "format")
+
+					groups.AddGroup("Graph")
+
+					fs.IntVar(&flags.Component, "component", 0, "Number of the connected component of the call graph to export, starting from 1, 0 means all")
+					fs.StringVar(&flags.Function, "function", "", "Name of the function, only the functions at most --depth calls away from which are exported, like 'Foo::bar'")
+					fs.IntVar(&flags.Depth, "depth", 1, "Maximum number of calls from the --function function in any direction")
+					fs.BoolVar(&flags.ColoredOnly, "colored-only", false, "Export only the colored functions and the functions on the paths between them")
+					fs.BoolVar(&flags.CollapseTransparent, "collapse-transparent", false, "Replace the paths between colored functions through uncolored ones with single edges")
+
+					groups.Add("Graph", "component")
+					groups.Add("Graph", "function")
+					groups.Add("Graph", "depth")
+					groups.Add("Graph", "colored-only")
+					groups.Add("Graph", "collapse-transparent")
+
+					ctx.CustomFlags = flags
+					return fs, groups
+				},
+				Action: func(ctx *cmd.AppContext) (int, error) {
+					return Graph(ctx, context)
+				},
+			})
 		},
 	})
 	if err != nil {
@@ -5,5 +5,6 @@ go 1.16
 require (
 	github.com/VKCOM/noverify v0.4.1-0.20210820112310-17cd2560f7a0
 	github.com/i582/cfmt v1.3.0
+	github.com/xeipuuv/gojsonschema v1.2.0
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,154 @@
+package baseline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+// Version is the version of the format of the baseline file.
+const Version = 1
+
+// Baseline is the list of the accepted violations, which are not reported.
+type Baseline struct {
+	Version int      `json:"version"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Entry is an accepted violation.
+//
+// Violations are matched by the fingerprint, other fields are
+// written so that the file can be reviewed and pruned by hand.
+type Entry struct {
+	Fingerprint string `json:"fingerprint"`
+	Ruleset     string `json:"ruleset"`
+	Rule        string `json:"rule"`
+	First       string `json:"first"`
+	Last        string `json:"last"`
+}
+
+// New returns the baseline that accepts all the violations of the reports.
+func New(reports []*pipes.ColorReport) *Baseline {
+	b := &Baseline{Version: Version, Entries: []*Entry{}}
+
+	seen := make(map[string]bool, len(reports))
+	for _, report := range reports {
+		if seen[report.Fingerprint] {
+			continue
+		}
+		seen[report.Fingerprint] = true
+
+		var ruleset string
+		if r := report.Palette.RulesetOf(report.Rule); r != nil {
+			ruleset = r.Name
+		}
+
+		first, last := report.ColoredEnds()
+		b.Entries = append(b.Entries, &Entry{
+			Fingerprint: report.Fingerprint,
+			Ruleset:     ruleset,
+			Rule:        report.Rule.String(report.Palette),
+			First:       first,
+			Last:        last,
+		})
+	}
+
+	sort.Slice(b.Entries, func(i, j int) bool {
+		if b.Entries[i].Ruleset != b.Entries[j].Ruleset {
+			return b.Entries[i].Ruleset < b.Entries[j].Ruleset
+		}
+		return b.Entries[i].Fingerprint < b.Entries[j].Fingerprint
+	})
+
+	return b
+}
+
+// Filter returns the reports of the violations that are not in the baseline,
+// the number of the suppressed reports and the entries of the baseline that
+// no longer occur, that is, the fixed violations.
+func (b *Baseline) Filter(reports []*pipes.ColorReport) (left []*pipes.ColorReport, suppressed int, fixed []*Entry) {
+	entries := make(map[string]*Entry, len(b.Entries))
+	for _, entry := range b.Entries {
+		entries[entry.Fingerprint] = entry
+	}
+
+	occurred := make(map[string]bool, len(b.Entries))
+	for _, report := range reports {
+		if _, ok := entries[report.Fingerprint]; ok {
+			occurred[report.Fingerprint] = true
+			suppressed++
+			continue
+		}
+
+		left = append(left, report)
+	}
+
+	for _, entry := range b.Entries {
+		if !occurred[entry.Fingerprint] {
+			fixed = append(fixed, entry)
+		}
+	}
+
+	return left, suppressed, fixed
+}
+
+// WriteToFile writes the baseline to the file in JSON format.
+func (b *Baseline) WriteToFile(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path, append(data, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf(`cannot write baseline file '%s': %v`, path, err)
+	}
+
+	return nil
+}
+
+// OpenFromFile reads the baseline from the file.
+func OpenFromFile(path string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		var perr *fs.PathError
+		if errors.As(err, &perr) {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				absPath = path
+			}
+
+			return nil, fmt.Errorf(`cannot open baseline file '%s', file not found. Full path: %s`, path, absPath)
+		}
+
+		return nil, fmt.Errorf(`cannot open baseline file '%s': %v`, path, err)
+	}
+
+	return Read(path, data)
+}
+
+// Read interprets the passed text as a baseline in JSON format.
+func Read(path string, data []byte) (*Baseline, error) {
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf(`invalid baseline file '%s': %v`, path, err)
+	}
+
+	if b.Version != Version {
+		return nil, fmt.Errorf(`invalid baseline file '%s': unsupported version %d, expected %d, regenerate it with the --generate-baseline option`, path, b.Version, Version)
+	}
+
+	for i, entry := range b.Entries {
+		if entry == nil || entry.Fingerprint == "" {
+			return nil, fmt.Errorf(`invalid baseline file '%s': entry %d has no fingerprint`, path, i+1)
+		}
+	}
+
+	return b, nil
+}
@@ -1,6 +1,8 @@
 package callgraph
 
 import (
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/palette"
 	"github.com/vkcom/nocolor/internal/symbols"
 )
 
@@ -30,27 +32,120 @@ func removeHelper(s Nodes, i int) Nodes {
 	return s[:len(s)-1]
 }
 
-// RawGraph is a map that stores all the functions that are called
-// in the function or the function in which the function is called.
-type RawGraph map[*Node]Nodes
-
 // Graph is a structure for storing the complete call graph, as
 // well as the functions that are included in it.
+//
+// The nodes of the graph have dense IDs from 0 to Size()-1, so the
+// visited sets can be slices, and the edges are stored in the
+// compressed sparse row format.
 type Graph struct {
+	// Functions are the nodes of the graph that are not removed.
 	Functions Nodes
+	// Nodes are all the nodes of the graph by their IDs.
+	Nodes Nodes
+
+	next adjacency
+	prev adjacency
+
+	removed []bool
+}
+
+// adjacency stores the edges of the node with the ID i
+// in edges[offsets[i]:offsets[i+1]].
+type adjacency struct {
+	offsets []int32
+	edges   []int32
+}
+
+func (a *adjacency) of(id int32) []int32 {
+	return a.edges[a.offsets[id]:a.offsets[id+1]]
+}
+
+// NewGraph creates a graph from the passed nodes and sets their IDs.
+//
+// Edges with the nodes that are not passed are skipped.
+func NewGraph(nodes Nodes) *Graph {
+	g := &Graph{
+		Functions: append(Nodes(nil), nodes...),
+		Nodes:     append(Nodes(nil), nodes...),
+		removed:   make([]bool, len(nodes)),
+	}
+
+	for i, node := range nodes {
+		node.ID = int32(i)
+	}
+
+	inGraph := func(node *Node) bool {
+		return int(node.ID) < len(g.Nodes) && g.Nodes[node.ID] == node
+	}
+
+	g.next = newAdjacency(nodes, func(node *Node) Nodes { return node.Next }, inGraph)
+	g.prev = newAdjacency(nodes, func(node *Node) Nodes { return node.Prev }, inGraph)
 
-	Graph    RawGraph
-	RevGraph RawGraph
+	return g
+}
+
+func newAdjacency(nodes Nodes, edgesOf func(*Node) Nodes, inGraph func(*Node) bool) adjacency {
+	count := 0
+	for _, node := range nodes {
+		count += len(edgesOf(node))
+	}
+
+	a := adjacency{
+		offsets: make([]int32, 0, len(nodes)+1),
+		edges:   make([]int32, 0, count),
+	}
+
+	for _, node := range nodes {
+		a.offsets = append(a.offsets, int32(len(a.edges)))
+
+		for _, other := range edgesOf(node) {
+			if inGraph(other) {
+				a.edges = append(a.edges, other.ID)
+			}
+		}
+	}
+	a.offsets = append(a.offsets, int32(len(a.edges)))
+
+	return a
+}
+
+// Size returns the number of the nodes in the graph, including removed ones.
+func (g *Graph) Size() int {
+	return len(g.Nodes)
+}
+
+// NextIDs returns the IDs of the nodes called from the node with
+// the passed ID, including the removed ones, see Removed.
+func (g *Graph) NextIDs(id int32) []int32 {
+	return g.next.of(id)
+}
+
+// PrevIDs returns the IDs of the nodes that call the node with
+// the passed ID, including the removed ones, see Removed.
+func (g *Graph) PrevIDs(id int32) []int32 {
+	return g.prev.of(id)
+}
+
+// Removed checks if the node with the passed ID is removed from the graph.
+func (g *Graph) Removed(id int32) bool {
+	return g.removed[id]
 }
 
 // Remove is a function that removes the passed node from the Graph.
 //
-// Note: Node is not removed from the RevGraph.
+// Note: Node is not removed from the Functions.
 func (g *Graph) Remove(node *Node) {
-	callers := g.RevGraph[node]
-	for _, caller := range callers {
-		g.Graph[caller] = g.Graph[caller].Remove(node)
-	}
+	g.removed[node.ID] = true
+}
+
+// Copy returns a copy of the graph from which nodes can be
+// removed independently, the nodes and edges are shared.
+func (g *Graph) Copy() *Graph {
+	graph := *g
+	graph.Functions = append(Nodes(nil), g.Functions...)
+	graph.removed = append([]bool(nil), g.removed...)
+	return &graph
 }
 
 // Node is a structure for storing information about the functions that call the
@@ -59,19 +154,63 @@ func (g *Graph) Remove(node *Node) {
 type Node struct {
 	Function *symbols.Function
 
+	// ID is the index of the node in the Nodes of its graph.
+	ID int32
+
 	// Next is an array of functions that are called from the current one.
 	// Prev is an array of functions that call the current one.
 	//
 	// All functions are always contained here, in contrast to Graph,
-	// where some functions can be deleted.
+	// where some functions can be removed.
 	// Use these fields only if you need to know if there is a connection,
 	// but for other, use the Graph.
 	Next Nodes
 	Prev Nodes
 
+	// NextKinds contains the kinds of the calls of the
+	// functions from Next, in the same order.
+	NextKinds []edgekind.Kind
+	// NextCallSites contains the positions of the calls
+	// of the functions from Next, in the same order.
+	NextCallSites []symbols.CallSite
+
 	// Pointer to a slice containing the
 	// following nodes that have colors.
 	NextWithColors *Nodes
+	// NextScopes contains the colors of the scoped removers, like
+	// '@color remover:db', through which the functions from NextWithColors
+	// are reached, in the same order, so the functions with these colors
+	// are not reachable through them. It is nil if there are no such colors.
+	NextScopes []palette.ColorMasks
+}
+
+// NextScope returns the colors that are not reachable through
+// the function from NextWithColors with the passed index.
+func (n *Node) NextScope(index int) palette.ColorMasks {
+	if n.NextScopes == nil {
+		return nil
+	}
+	return n.NextScopes[index]
+}
+
+// KindOf returns the kinds of the call of the next function.
+func (n *Node) KindOf(next *Node) edgekind.Kind {
+	for i, node := range n.Next {
+		if node == next {
+			return n.NextKinds[i]
+		}
+	}
+	return edgekind.None
+}
+
+// CallSiteOf returns the position of the call of the next function.
+func (n *Node) CallSiteOf(next *Node) symbols.CallSite {
+	for i, node := range n.Next {
+		if node == next {
+			return n.NextCallSites[i]
+		}
+	}
+	return symbols.CallSite{}
 }
 
 // String method for debugging.
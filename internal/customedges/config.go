@@ -0,0 +1,171 @@
+package customedges
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// ConfigRule is a raw rule from the config file.
+type ConfigRule struct {
+	From        string `yaml:"from"`
+	To          string `yaml:"to"`
+	ToAttribute string `yaml:"to-attribute"`
+}
+
+// Rule describes extra edges from every function that matches
+// the From pattern to every function that matches the To
+// pattern and has the ToAttribute attribute.
+//
+// Patterns are function names, where '*' matches any sequence
+// of characters, for example 'App\Controllers\*::handle*'.
+type Rule struct {
+	Group string
+
+	From        *regexp.Regexp
+	To          *regexp.Regexp
+	ToAttribute string
+}
+
+// MatchFrom checks if the function is a caller for the rule.
+func (r *Rule) MatchFrom(fun *symbols.Function) bool {
+	if fun.Type != symbols.LocalFunc && fun.Type != symbols.ExternFunc {
+		return false
+	}
+
+	return r.From.MatchString(strings.TrimPrefix(fun.Name, `\`))
+}
+
+// MatchTo checks if the function is a callee for the rule.
+func (r *Rule) MatchTo(fun *symbols.Function) bool {
+	if fun.Type != symbols.LocalFunc && fun.Type != symbols.ExternFunc {
+		return false
+	}
+
+	if r.To != nil && !r.To.MatchString(strings.TrimPrefix(fun.Name, `\`)) {
+		return false
+	}
+
+	if r.ToAttribute != "" && !HasAttribute(fun, r.ToAttribute) {
+		return false
+	}
+
+	return true
+}
+
+// HasAttribute checks if the function has an attribute with the passed
+// name, which can be either fully qualified or without a namespace.
+func HasAttribute(fun *symbols.Function, name string) bool {
+	for _, attr := range fun.Attributes {
+		attr = strings.TrimPrefix(attr, `\`)
+
+		if strings.EqualFold(attr, name) {
+			return true
+		}
+
+		if len(attr) > len(name) && strings.EqualFold(attr[len(attr)-len(name)-1:], `\`+name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config is a structure for storing all custom edges rules.
+type Config struct {
+	Rules []*Rule
+}
+
+// OpenConfigFromFile returns a ready-use config from a file.
+func OpenConfigFromFile(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		var perr *fs.PathError
+		if errors.As(err, &perr) {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				absPath = path
+			}
+
+			return nil, fmt.Errorf(`cannot open edges file '%s', file not found. Full path: %s`, path, absPath)
+		}
+
+		return nil, fmt.Errorf(`cannot open edges file '%s': %v`, path, err)
+	}
+
+	return ReadConfigFileYAML(path, data)
+}
+
+// The ReadConfigFileYAML function interprets the passed text as a
+// config in YAML format and returns a ready-made config.
+func ReadConfigFileYAML(path string, data []byte) (*Config, error) {
+	var groups map[string][]ConfigRule
+
+	err := yaml.UnmarshalStrict(data, &groups)
+	if err != nil {
+		return nil, fmt.Errorf(`could not parse edges file '%s'. 
+The correct format is:
+
+group description:
+- from: Caller::method
+  to: Callee\*::method
+- from: Caller::method
+  to-attribute: Attribute
+
+(optionally with many groups)`, path)
+	}
+
+	return parseConfigRaw(path, groups)
+}
+
+func parseConfigRaw(path string, groups map[string][]ConfigRule) (*Config, error) {
+	config := &Config{}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, raw := range groups[name] {
+			if raw.From == "" {
+				return nil, fmt.Errorf("error in edges file '%s': rule in group '%s' has no 'from' pattern", path, name)
+			}
+			if raw.To == "" && raw.ToAttribute == "" {
+				return nil, fmt.Errorf("error in edges file '%s': rule in group '%s' has neither 'to' nor 'to-attribute'", path, name)
+			}
+
+			rule := &Rule{
+				Group:       name,
+				From:        CompilePattern(raw.From),
+				ToAttribute: strings.TrimPrefix(raw.ToAttribute, `\`),
+			}
+			if raw.To != "" {
+				rule.To = CompilePattern(raw.To)
+			}
+
+			config.Rules = append(config.Rules, rule)
+		}
+	}
+
+	return config, nil
+}
+
+// CompilePattern converts a pattern like 'App\*::handle*'
+// into a case-insensitive regular expression.
+func CompilePattern(pattern string) *regexp.Regexp {
+	pattern = strings.TrimPrefix(pattern, `\`)
+	pattern = regexp.QuoteMeta(pattern)
+	pattern = strings.ReplaceAll(pattern, `\*`, `.*`)
+	return regexp.MustCompile(`(?i)^` + pattern + `$`)
+}
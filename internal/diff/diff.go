@@ -0,0 +1,345 @@
+package diff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+// Range is the range of the changed lines of the
+// new version of the file, both ends included.
+type Range struct {
+	From, To int
+}
+
+// Changes are the changed lines of the files of the unified diff.
+//
+// The lines of the removed code are changes at the position where it was,
+// the files are only from the new version, removed files are skipped.
+type Changes struct {
+	// Files are the ranges of the changed lines by the paths from the diff,
+	// the ranges are sorted and do not overlap.
+	Files map[string][]Range
+
+	// Root is the directory to which the paths of the diff are relative,
+	// if empty, the files are matched by the paths they end with.
+	Root string
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Parse reads the unified diff, like the output of 'git diff' or 'diff -u'.
+func Parse(r io.Reader) (*Changes, error) {
+	changes := &Changes{Files: map[string][]Range{}}
+
+	var oldPath, file string
+	var oldLeft, newLeft, line int
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		text := scanner.Text()
+
+		// Inside the hunk, the lines are counted, so that the removed
+		// or added lines like '--- a' are not taken for headers.
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				changes.add(file, line)
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				changes.add(file, line)
+				oldLeft--
+			case strings.HasPrefix(text, " ") || text == "":
+				line++
+				oldLeft--
+				newLeft--
+			case strings.HasPrefix(text, `\`):
+				// '\ No newline at end of file'.
+			default:
+				return nil, fmt.Errorf("line %d: unexpected line in the hunk: %q", lineNumber, text)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "--- "):
+			oldPath = headerPath(text[len("--- "):])
+
+		case strings.HasPrefix(text, "+++ "):
+			file = headerPath(text[len("+++ "):])
+			if file == "/dev/null" {
+				continue
+			}
+
+			// Git adds the 'a/' and 'b/' prefixes to the paths.
+			if strings.HasPrefix(file, "b/") && (strings.HasPrefix(oldPath, "a/") || oldPath == "/dev/null") {
+				file = file[len("b/"):]
+			}
+			file = strings.TrimPrefix(file, "./")
+
+		case strings.HasPrefix(text, "@@ "):
+			match := hunkHeader.FindStringSubmatch(text)
+			if match == nil {
+				return nil, fmt.Errorf("line %d: invalid hunk header: %q", lineNumber, text)
+			}
+			if file == "" && oldPath == "" {
+				return nil, fmt.Errorf("line %d: hunk without the '+++' header of the file", lineNumber)
+			}
+
+			oldLeft = hunkCount(match[1])
+			line, _ = strconv.Atoi(match[2])
+			newLeft = hunkCount(match[3])
+
+			if file == "/dev/null" {
+				file = ""
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for file, ranges := range changes.Files {
+		changes.Files[file] = mergeRanges(ranges)
+	}
+
+	return changes, nil
+}
+
+// OpenFromFile reads the unified diff from the file, or from the standard
+// input if the path is '-', the root is resolved from the working directory.
+func OpenFromFile(path string) (*Changes, error) {
+	changes, err := openFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if wd, err := os.Getwd(); err == nil {
+		changes.ResolveRoot(wd)
+	}
+
+	return changes, nil
+}
+
+func openFromFile(path string) (*Changes, error) {
+	if path == "-" {
+		changes, err := Parse(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid diff from the standard input: %v", err)
+		}
+		return changes, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		var perr *fs.PathError
+		if errors.As(err, &perr) {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				absPath = path
+			}
+
+			return nil, fmt.Errorf(`cannot open diff file '%s', file not found. Full path: %s`, path, absPath)
+		}
+
+		return nil, fmt.Errorf(`cannot open diff file '%s': %v`, path, err)
+	}
+	defer file.Close()
+
+	changes, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid diff file '%s': %v`, path, err)
+	}
+
+	return changes, nil
+}
+
+// ResolveRoot sets the root of the paths of the diff to the closest
+// directory, starting from the passed one and up to the root of the file
+// system, in which some changed file exists, like the root of the repository.
+//
+// If there is no such directory, the root is not set.
+func (c *Changes) ResolveRoot(dir string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+
+	paths := make([]string, 0, len(c.Files))
+	for path := range c.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for {
+		for _, path := range paths {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path))); err == nil {
+				c.Root = dir
+				return
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+}
+
+// Contains checks if any of the lines from the first to the last
+// one of the file is changed, the last one is ignored if zero.
+func (c *Changes) Contains(filename string, first, last int) bool {
+	if last < first {
+		last = first
+	}
+
+	for _, r := range c.ranges(filename) {
+		if r.From <= last && first <= r.To {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter returns the reports of the violations where some function
+// of the chain or some call of the chain is in the changed lines.
+func (c *Changes) Filter(reports []*pipes.ColorReport) []*pipes.ColorReport {
+	var touched []*pipes.ColorReport
+	for _, report := range reports {
+		if c.touches(report) {
+			touched = append(touched, report)
+		}
+	}
+
+	return touched
+}
+
+func (c *Changes) touches(r *pipes.ColorReport) bool {
+	for _, node := range r.CallChain {
+		pos := node.Function.Pos
+		if pos.Filename != "" && c.Contains(pos.Filename, int(pos.Line), int(pos.EndLine)) {
+			return true
+		}
+	}
+
+	for _, site := range r.CallSites {
+		if !site.Empty() && c.Contains(site.Filename, site.Line, site.Line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ranges returns the changed lines of the file.
+//
+// The paths of the diff are relative to the root of the repository, which
+// may be not the working directory. If the root is unknown, the file matches
+// the longest path that it ends with, since two different paths of the same
+// length cannot both be the ends of the file, the match is unambiguous.
+func (c *Changes) ranges(filename string) []Range {
+	absPath, err := filepath.Abs(filename)
+	if err == nil {
+		filename = absPath
+	}
+	filename = filepath.ToSlash(filename)
+
+	if c.Root != "" {
+		path, err := filepath.Rel(c.Root, filename)
+		if err != nil {
+			return nil
+		}
+		return c.Files[filepath.ToSlash(path)]
+	}
+
+	var match string
+	for path := range c.Files {
+		if filename != path && !strings.HasSuffix(filename, "/"+path) {
+			continue
+		}
+
+		if len(path) > len(match) {
+			match = path
+		}
+	}
+	if match == "" {
+		return nil
+	}
+
+	return c.Files[match]
+}
+
+func (c *Changes) add(file string, line int) {
+	if file == "" {
+		// The lines of the removed file.
+		return
+	}
+	if line < 1 {
+		line = 1
+	}
+
+	ranges := c.Files[file]
+	if n := len(ranges); n != 0 && ranges[n-1].To >= line-1 {
+		if ranges[n-1].To < line {
+			ranges[n-1].To = line
+		}
+		return
+	}
+
+	c.Files[file] = append(ranges, Range{From: line, To: line})
+}
+
+// headerPath returns the path from the '---' or '+++' header,
+// the timestamp of 'diff -u' is separated by a tab.
+func headerPath(header string) string {
+	if i := strings.IndexByte(header, '\t'); i != -1 {
+		header = header[:i]
+	}
+
+	return strings.TrimSpace(header)
+}
+
+// hunkCount returns the number of lines of the hunk, which is 1 if omitted.
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+func mergeRanges(ranges []Range) []Range {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].From < ranges[j].From
+	})
+
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n != 0 && merged[n-1].To >= r.From-1 {
+			if merged[n-1].To < r.To {
+				merged[n-1].To = r.To
+			}
+			continue
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
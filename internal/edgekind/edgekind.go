@@ -0,0 +1,114 @@
+package edgekind
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind describes how one function calls another.
+//
+// Since a function can call another in several ways at
+// once, Kind is a bit mask that can contain several kinds.
+type Kind uint16
+
+const (
+	// Direct is a call of a function by its name.
+	Direct Kind = 1 << iota
+	// Static is a call of a static method.
+	Static
+	// Method is a call of an instance method.
+	Method
+	// Magic is an implicit call of a magic method like '__get'
+	// or an interface method like 'ArrayAccess::offsetGet'.
+	Magic
+	// Include is an inclusion of a file with require or include.
+	Include
+	// New is a call of a constructor.
+	New
+	// CallableRef is a call of a value like '$f()' resolved to '__invoke'.
+	CallableRef
+	// Custom is an edge from the custom edges config.
+	Custom
+	// TypeRef is a reference to a class by type, for example, in
+	// a type hint or instanceof, used in the dependencies mode.
+	TypeRef
+	// Pseudo is a usage of a superglobal or a language
+	// construct, like 'echo' or 'eval'.
+	Pseudo
+)
+
+// None is an empty set of kinds.
+const None Kind = 0
+
+var names = []struct {
+	kind Kind
+	name string
+}{
+	{Direct, "direct"},
+	{Static, "static"},
+	{Method, "method"},
+	{Magic, "magic"},
+	{Include, "include"},
+	{New, "new"},
+	{CallableRef, "callable-ref"},
+	{Custom, "custom"},
+	{TypeRef, "type-ref"},
+	{Pseudo, "pseudo"},
+}
+
+// Parse parses the names of kinds separated by commas or spaces.
+func Parse(value string) (Kind, error) {
+	var res Kind
+
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+	for _, field := range fields {
+		kind, ok := byName(field)
+		if !ok {
+			return None, fmt.Errorf("unknown edge kind '%s', available kinds: %s", field, availableNames())
+		}
+		res |= kind
+	}
+
+	return res, nil
+}
+
+func byName(name string) (Kind, bool) {
+	for _, n := range names {
+		if n.name == name {
+			return n.kind, true
+		}
+	}
+	return None, false
+}
+
+func availableNames() string {
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		res = append(res, n.name)
+	}
+	return strings.Join(res, ", ")
+}
+
+// Contains checks if all the kinds of other are contained in k.
+func (k Kind) Contains(other Kind) bool {
+	return k&other == other
+}
+
+// Names returns the names of all kinds contained in k.
+func (k Kind) Names() []string {
+	var res []string
+	for _, n := range names {
+		if k&n.kind != 0 {
+			res = append(res, n.name)
+		}
+	}
+	return res
+}
+
+// String returns the names of kinds separated by '|'.
+func (k Kind) String() string {
+	return strings.Join(k.Names(), "|")
+}
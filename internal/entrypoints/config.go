@@ -0,0 +1,188 @@
+package entrypoints
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/vkcom/nocolor/internal/customedges"
+	"github.com/vkcom/nocolor/internal/namegen"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// ConfigRule is a raw rule from the config file.
+type ConfigRule struct {
+	File      string `yaml:"file"`
+	Function  string `yaml:"function"`
+	Color     string `yaml:"color"`
+	Attribute string `yaml:"attribute"`
+}
+
+// Rule describes the functions that are entry points.
+//
+// A rule either matches the scopes of the files under the File path,
+// or the functions that match all of the Function pattern, the Color
+// and the Attribute that are set.
+//
+// Patterns are function names, where '*' matches any sequence
+// of characters, for example 'App\Controllers\*::*Action'.
+type Rule struct {
+	Group string
+
+	File      string
+	Function  *regexp.Regexp
+	Color     palette.Color
+	HasColor  bool
+	Attribute string
+}
+
+// Match checks if the function is an entry point for the rule.
+func (r *Rule) Match(fun *symbols.Function) bool {
+	if r.File != "" {
+		return fun.Type == symbols.MainFunc && underPath(namegen.FileFromFileFunction(fun.Name), r.File)
+	}
+
+	if fun.Type != symbols.LocalFunc && fun.Type != symbols.ExternFunc {
+		return false
+	}
+
+	if r.Function != nil && !r.Function.MatchString(strings.TrimPrefix(fun.Name, `\`)) {
+		return false
+	}
+
+	if r.HasColor && !fun.Colors.Contains(r.Color) {
+		return false
+	}
+
+	if r.Attribute != "" && !customedges.HasAttribute(fun, r.Attribute) {
+		return false
+	}
+
+	return true
+}
+
+// underPath checks if the file is the passed path or is in it.
+func underPath(filename, path string) bool {
+	filename = absPath(filename)
+	return filename == path || strings.HasPrefix(filename, path+string(filepath.Separator))
+}
+
+func absPath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return absPath
+}
+
+// Config is a structure for storing all entry points rules.
+type Config struct {
+	Rules []*Rule
+}
+
+// IsEntryPoint checks if the function is an entry point for any rule.
+func (c *Config) IsEntryPoint(fun *symbols.Function) bool {
+	for _, rule := range c.Rules {
+		if rule.Match(fun) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenConfigFromFile returns a ready-use config from a file.
+func OpenConfigFromFile(path string, pal *palette.Palette) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		var perr *fs.PathError
+		if errors.As(err, &perr) {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				absPath = path
+			}
+
+			return nil, fmt.Errorf(`cannot open entry points file '%s', file not found. Full path: %s`, path, absPath)
+		}
+
+		return nil, fmt.Errorf(`cannot open entry points file '%s': %v`, path, err)
+	}
+
+	return ReadConfigFileYAML(path, data, pal)
+}
+
+// The ReadConfigFileYAML function interprets the passed text as a
+// config in YAML format and returns a ready-made config.
+//
+// Colors of the rules must be present in the passed palette.
+func ReadConfigFileYAML(path string, data []byte, pal *palette.Palette) (*Config, error) {
+	var groups map[string][]ConfigRule
+
+	err := yaml.UnmarshalStrict(data, &groups)
+	if err != nil {
+		return nil, fmt.Errorf(`could not parse entry points file '%s'.
+The correct format is:
+
+group description:
+- file: ./public
+- function: App\Controllers\*::*Action
+- color: entry
+- attribute: Route
+
+(optionally with many groups)`, path)
+	}
+
+	return parseConfigRaw(path, groups, pal)
+}
+
+func parseConfigRaw(path string, groups map[string][]ConfigRule, pal *palette.Palette) (*Config, error) {
+	config := &Config{}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, raw := range groups[name] {
+			if raw.File == "" && raw.Function == "" && raw.Color == "" && raw.Attribute == "" {
+				return nil, fmt.Errorf("error in entry points file '%s': rule in group '%s' has neither 'file', 'function', 'color' nor 'attribute'", path, name)
+			}
+			if raw.File != "" && (raw.Function != "" || raw.Color != "" || raw.Attribute != "") {
+				return nil, fmt.Errorf("error in entry points file '%s': rule in group '%s' has 'file' with other fields, file scopes have no names, colors and attributes", path, name)
+			}
+
+			rule := &Rule{
+				Group:     name,
+				Attribute: strings.TrimPrefix(raw.Attribute, `\`),
+			}
+			if raw.File != "" {
+				rule.File = absPath(raw.File)
+			}
+			if raw.Function != "" {
+				rule.Function = customedges.CompilePattern(raw.Function)
+			}
+			if raw.Color != "" {
+				if !pal.ColorExists(raw.Color) {
+					return nil, fmt.Errorf("error in entry points file '%s': rule in group '%s' uses color '%s' that is not in the palette", path, name, raw.Color)
+				}
+
+				rule.Color = pal.GetColorByName(raw.Color)
+				rule.HasColor = true
+			}
+
+			config.Rules = append(config.Rules, rule)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,297 @@
+package graphexport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vkcom/nocolor/internal/callgraph"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pathutil"
+	"github.com/vkcom/nocolor/internal/pipes"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// Options describe the part of the call graph to export.
+type Options struct {
+	// Component is the number of the connected component to export,
+	// starting from 1 in the order of NodesToGraphs, 0 means all.
+	Component int
+
+	// Function is the name of the function, only the nodes at most
+	// Depth calls away from which are exported, in any direction.
+	Function string
+	Depth    int
+
+	// ColoredOnly limits the graph to the colored functions
+	// and the functions on the paths between them.
+	ColoredOnly bool
+
+	// CollapseTransparent replaces the paths between colored functions
+	// through transparent ones with single edges, the transparent
+	// functions are not exported.
+	CollapseTransparent bool
+}
+
+// Graph is the exported part of the call graph.
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+}
+
+// Node is an exported function.
+type Node struct {
+	ID     string
+	Name   string
+	Colors []string
+	File   string
+	Line   int
+}
+
+// Edge is an exported call.
+type Edge struct {
+	From, To *Node
+	Kind     edgekind.Kind
+
+	// Through is the number of transparent functions
+	// collapsed into the edge, see CollapseTransparent.
+	Through int
+
+	// CallSite is the position of the call in the From function.
+	CallSite symbols.CallSite
+}
+
+// Build returns the part of the graph of the nodes described in the options.
+//
+// The nodes must be the nodes that FunctionsToNodes returns.
+func Build(nodes callgraph.Nodes, pal *palette.Palette, opts Options) (*Graph, error) {
+	if opts.Component != 0 {
+		graphs := pipes.NodesToGraphs(nodes)
+		if opts.Component < 0 || opts.Component > len(graphs) {
+			return nil, fmt.Errorf("component %d does not exist, there are %d components from 1 to %d", opts.Component, len(graphs), len(graphs))
+		}
+
+		nodes = graphs[opts.Component-1].Nodes
+	}
+
+	selected := make(map[*callgraph.Node]bool, len(nodes))
+	for _, node := range nodes {
+		selected[node] = true
+	}
+
+	if opts.Function != "" {
+		center := findFunction(nodes, opts.Function)
+		if center == nil {
+			return nil, fmt.Errorf("function '%s' is not found in the call graph", opts.Function)
+		}
+
+		selected = neighborhood(center, opts.Depth, selected)
+	}
+
+	if opts.ColoredOnly {
+		selected = connectingColored(nodes, selected)
+	}
+
+	graph := &Graph{}
+	exported := make(map[*callgraph.Node]*Node, len(selected))
+	for _, node := range nodes {
+		if !selected[node] || (opts.CollapseTransparent && !node.Function.HasColors()) {
+			continue
+		}
+
+		exported[node] = newNode(node.Function, pal, len(graph.Nodes))
+		graph.Nodes = append(graph.Nodes, exported[node])
+	}
+
+	for _, node := range nodes {
+		from, ok := exported[node]
+		if !ok {
+			continue
+		}
+
+		if opts.CollapseTransparent {
+			graph.Edges = append(graph.Edges, collapsedEdges(node, from, selected, exported)...)
+			continue
+		}
+
+		for i, next := range node.Next {
+			to, ok := exported[next]
+			if !ok {
+				continue
+			}
+
+			graph.Edges = append(graph.Edges, &Edge{
+				From:     from,
+				To:       to,
+				Kind:     node.NextKinds[i],
+				CallSite: node.NextCallSites[i],
+			})
+		}
+	}
+
+	return graph, nil
+}
+
+func newNode(fun *symbols.Function, pal *palette.Palette, index int) *Node {
+	node := &Node{
+		ID:     fmt.Sprintf("n%d", index),
+		Name:   fun.HumanReadableName(),
+		Colors: []string{},
+		Line:   int(fun.Pos.Line),
+	}
+	if fun.Pos.Filename != "" {
+		node.File = pathutil.Relative(fun.Pos.Filename)
+	}
+
+	for _, color := range fun.Colors.Colors {
+		node.Colors = append(node.Colors, pal.GetNameByColor(color))
+	}
+
+	return node
+}
+
+// findFunction finds the node of the function by its name with
+// or without the leading backslash, like 'Foo::bar' or '\Foo::bar'.
+func findFunction(nodes callgraph.Nodes, name string) *callgraph.Node {
+	name = strings.TrimPrefix(name, `\`)
+
+	for _, node := range nodes {
+		if strings.TrimPrefix(node.Function.Name, `\`) == name || node.Function.HumanReadableName() == name {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// neighborhood returns the selected nodes at most depth calls away
+// from the center, the calls are followed in both directions.
+func neighborhood(center *callgraph.Node, depth int, selected map[*callgraph.Node]bool) map[*callgraph.Node]bool {
+	near := map[*callgraph.Node]bool{center: true}
+	layer := callgraph.Nodes{center}
+
+	for i := 0; i < depth && len(layer) != 0; i++ {
+		var next callgraph.Nodes
+
+		for _, node := range layer {
+			for _, neighbors := range []callgraph.Nodes{node.Next, node.Prev} {
+				for _, neighbor := range neighbors {
+					if near[neighbor] || !selected[neighbor] {
+						continue
+					}
+
+					near[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+
+		layer = next
+	}
+
+	return near
+}
+
+// connectingColored returns the selected nodes that are colored or
+// lie on a path from one colored node to another one.
+func connectingColored(nodes callgraph.Nodes, selected map[*callgraph.Node]bool) map[*callgraph.Node]bool {
+	var colored callgraph.Nodes
+	for _, node := range nodes {
+		if selected[node] && node.Function.HasColors() {
+			colored = append(colored, node)
+		}
+	}
+
+	fromColored := reachable(colored, selected, func(node *callgraph.Node) callgraph.Nodes { return node.Next })
+	toColored := reachable(colored, selected, func(node *callgraph.Node) callgraph.Nodes { return node.Prev })
+
+	connecting := make(map[*callgraph.Node]bool, len(colored))
+	for _, node := range nodes {
+		if !selected[node] {
+			continue
+		}
+
+		if node.Function.HasColors() || (fromColored[node] && toColored[node]) {
+			connecting[node] = true
+		}
+	}
+
+	return connecting
+}
+
+func reachable(from callgraph.Nodes, selected map[*callgraph.Node]bool, edgesOf func(*callgraph.Node) callgraph.Nodes) map[*callgraph.Node]bool {
+	visited := make(map[*callgraph.Node]bool, len(from))
+	queue := append(callgraph.Nodes(nil), from...)
+
+	for len(queue) != 0 {
+		node := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		for _, other := range edgesOf(node) {
+			if visited[other] || !selected[other] {
+				continue
+			}
+
+			visited[other] = true
+			queue = append(queue, other)
+		}
+	}
+
+	return visited
+}
+
+// collapsedEdges returns the edges from the colored node to the colored nodes
+// that it calls directly or through the transparent ones. For each of them,
+// the shortest path is taken, the kind of the edge is the kinds of its calls.
+func collapsedEdges(node *callgraph.Node, from *Node, selected map[*callgraph.Node]bool, exported map[*callgraph.Node]*Node) []*Edge {
+	type step struct {
+		node    *callgraph.Node
+		kind    edgekind.Kind
+		through int
+		site    symbols.CallSite
+	}
+
+	// The node itself is not visited, so the
+	// recursive calls of it are exported too.
+	var edges []*Edge
+	visited := map[*callgraph.Node]bool{}
+	queue := []step{{node: node}}
+
+	for len(queue) != 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for i, next := range cur.node.Next {
+			if visited[next] || !selected[next] {
+				continue
+			}
+			visited[next] = true
+
+			nextStep := step{
+				node:    next,
+				kind:    cur.kind | cur.node.NextKinds[i],
+				through: cur.through,
+				site:    cur.site,
+			}
+			if cur.node == node {
+				nextStep.site = cur.node.NextCallSites[i]
+			}
+
+			if to, ok := exported[next]; ok {
+				edges = append(edges, &Edge{
+					From:     from,
+					To:       to,
+					Kind:     nextStep.kind,
+					Through:  nextStep.through,
+					CallSite: nextStep.site,
+				})
+				continue
+			}
+
+			nextStep.through++
+			queue = append(queue, nextStep)
+		}
+	}
+
+	return edges
+}
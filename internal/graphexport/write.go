@@ -0,0 +1,214 @@
+package graphexport
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+// WriteDOT writes the graph in the Graphviz DOT format.
+//
+// Nodes are filled with the color of their first color,
+// edges are labeled with the kinds of the calls.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("digraph nocolor {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\", fontname=\"monospace\"];\n")
+	b.WriteString("  edge [fontname=\"monospace\", fontsize=10];\n")
+
+	for _, node := range g.Nodes {
+		label := node.Name
+		for _, color := range node.Colors {
+			label += "\n@" + color
+		}
+
+		attrs := "label=" + dotQuote(label)
+		if len(node.Colors) != 0 {
+			attrs += ", fillcolor=" + dotQuote(pipes.ColorFill(node.Colors[0]))
+		}
+		if node.File != "" {
+			attrs += ", tooltip=" + dotQuote(node.File+":"+strconv.Itoa(node.Line))
+		}
+
+		fmt.Fprintf(&b, "  %s [%s];\n", node.ID, attrs)
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", edge.From.ID, edge.To.ID, dotQuote(edge.label()))
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// label returns the kinds of the call and the number of the collapsed
+// transparent functions, like 'direct|new (through 2)'.
+func (e *Edge) label() string {
+	label := e.Kind.String()
+	if e.Through != 0 {
+		label += " (through " + strconv.Itoa(e.Through) + ")"
+	}
+	return label
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph in the GraphML format.
+//
+// The colors of the nodes are separated by spaces.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", Name: "name", Type: "string"},
+			{ID: "colors", For: "node", Name: "colors", Type: "string"},
+			{ID: "file", For: "node", Name: "file", Type: "string"},
+			{ID: "line", For: "node", Name: "line", Type: "int"},
+			{ID: "kind", For: "edge", Name: "kind", Type: "string"},
+			{ID: "through", For: "edge", Name: "through", Type: "int"},
+			{ID: "call_site", For: "edge", Name: "call_site", Type: "string"},
+		},
+		Graph: graphMLGraph{ID: "nocolor", EdgeDefault: "directed"},
+	}
+
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: node.ID,
+			Data: []graphMLData{
+				{Key: "name", Value: node.Name},
+				{Key: "colors", Value: strings.Join(node.Colors, " ")},
+				{Key: "file", Value: node.File},
+				{Key: "line", Value: strconv.Itoa(node.Line)},
+			},
+		})
+	}
+
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: edge.From.ID,
+			Target: edge.To.ID,
+			Data: []graphMLData{
+				{Key: "kind", Value: edge.Kind.String()},
+				{Key: "through", Value: strconv.Itoa(edge.Through)},
+				{Key: "call_site", Value: edge.CallSite.String()},
+			},
+		})
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, xml.Header+string(data)+"\n")
+	return err
+}
+
+type jsonGraph struct {
+	Nodes []*jsonNode `json:"nodes"`
+	Edges []*jsonEdge `json:"edges"`
+}
+
+type jsonNode struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Colors []string `json:"colors"`
+	File   string   `json:"file,omitempty"`
+	Line   int      `json:"line,omitempty"`
+}
+
+type jsonEdge struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Kinds    []string `json:"kinds"`
+	Through  int      `json:"through"`
+	CallSite string   `json:"call_site"`
+}
+
+// WriteJSON writes the graph in JSON format as
+// lists of nodes and edges between their IDs.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	doc := jsonGraph{
+		Nodes: make([]*jsonNode, 0, len(g.Nodes)),
+		Edges: make([]*jsonEdge, 0, len(g.Edges)),
+	}
+
+	for _, node := range g.Nodes {
+		doc.Nodes = append(doc.Nodes, &jsonNode{
+			ID:     node.ID,
+			Name:   node.Name,
+			Colors: node.Colors,
+			File:   node.File,
+			Line:   node.Line,
+		})
+	}
+
+	for _, edge := range g.Edges {
+		doc.Edges = append(doc.Edges, &jsonEdge{
+			From:     edge.From.ID,
+			To:       edge.To.ID,
+			Kinds:    edge.Kind.Names(),
+			Through:  edge.Through,
+			CallSite: edge.CallSite.String(),
+		})
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
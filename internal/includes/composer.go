@@ -0,0 +1,154 @@
+package includes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Autoload describes the classes autoloaded by composer
+// according to the 'psr-4' and 'classmap' sections.
+type Autoload struct {
+	psr4     []psr4Prefix
+	classmap []string
+}
+
+type psr4Prefix struct {
+	prefix string
+	dirs   []string
+}
+
+type composerAutoload struct {
+	PSR4     map[string]json.RawMessage `json:"psr-4"`
+	Classmap []string                   `json:"classmap"`
+}
+
+type composerConfig struct {
+	Autoload    composerAutoload `json:"autoload"`
+	AutoloadDev composerAutoload `json:"autoload-dev"`
+}
+
+// OpenComposerFromFile returns the autoload config from the composer.json file.
+func OpenComposerFromFile(path string) (*Autoload, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		var perr *fs.PathError
+		if errors.As(err, &perr) {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				absPath = path
+			}
+
+			return nil, fmt.Errorf(`cannot open composer file '%s', file not found. Full path: %s`, path, absPath)
+		}
+
+		return nil, fmt.Errorf(`cannot open composer file '%s': %v`, path, err)
+	}
+
+	return ReadComposerJSON(path, data)
+}
+
+// ReadComposerJSON interprets the passed text as a composer.json file.
+//
+// The paths in the file are relative to the directory of the file.
+func ReadComposerJSON(path string, data []byte) (*Autoload, error) {
+	var config composerConfig
+
+	err := json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf(`could not parse composer file '%s': %v`, path, err)
+	}
+
+	dir := filepath.Dir(path)
+	autoload := &Autoload{}
+
+	for _, section := range []composerAutoload{config.Autoload, config.AutoloadDev} {
+		for prefix, rawDirs := range section.PSR4 {
+			dirs, err := parsePSR4Dirs(rawDirs)
+			if err != nil {
+				return nil, fmt.Errorf(`error in composer file '%s' in psr-4 prefix '%s': %v`, path, prefix, err)
+			}
+
+			for i := range dirs {
+				dirs[i] = filepath.Join(dir, dirs[i])
+			}
+
+			autoload.psr4 = append(autoload.psr4, psr4Prefix{
+				prefix: strings.Trim(prefix, `\`),
+				dirs:   dirs,
+			})
+		}
+
+		for _, classmapPath := range section.Classmap {
+			autoload.classmap = append(autoload.classmap, filepath.Join(dir, classmapPath))
+		}
+	}
+
+	// The longest prefixes are checked first, as composer does.
+	sort.Slice(autoload.psr4, func(i, j int) bool {
+		if len(autoload.psr4[i].prefix) != len(autoload.psr4[j].prefix) {
+			return len(autoload.psr4[i].prefix) > len(autoload.psr4[j].prefix)
+		}
+		return autoload.psr4[i].prefix < autoload.psr4[j].prefix
+	})
+
+	return autoload, nil
+}
+
+// parsePSR4Dirs parses the directories of the prefix,
+// which can be either a string or a list of strings.
+func parsePSR4Dirs(raw json.RawMessage) ([]string, error) {
+	var dir string
+	if err := json.Unmarshal(raw, &dir); err == nil {
+		return []string{dir}, nil
+	}
+
+	var dirs []string
+	if err := json.Unmarshal(raw, &dirs); err != nil {
+		return nil, errors.New("the value must be a string or a list of strings")
+	}
+
+	return dirs, nil
+}
+
+// PSR4Files returns the files in which the class should
+// be declared according to the 'psr-4' section.
+func (a *Autoload) PSR4Files(className string) []string {
+	className = strings.TrimPrefix(className, `\`)
+
+	var files []string
+	for _, p := range a.psr4 {
+		rest := className
+		if p.prefix != "" {
+			if !strings.HasPrefix(className, p.prefix+`\`) {
+				continue
+			}
+			rest = className[len(p.prefix)+1:]
+		}
+
+		relPath := strings.ReplaceAll(rest, `\`, "/") + ".php"
+		for _, dir := range p.dirs {
+			files = append(files, filepath.Join(dir, relPath))
+		}
+	}
+
+	return files
+}
+
+// InClassmap checks if the file is covered by the 'classmap' section.
+func (a *Autoload) InClassmap(filename string) bool {
+	filename = filepath.Clean(filename)
+
+	for _, path := range a.classmap {
+		if filename == path || strings.HasPrefix(filename, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
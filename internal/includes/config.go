@@ -0,0 +1,65 @@
+package includes
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Config describes how the paths of included files and
+// the files of autoloaded classes are resolved.
+type Config struct {
+	// Roots are the directories in which the files included by
+	// a path that is neither absolute nor relative are searched,
+	// like the 'include_path' PHP option.
+	Roots []string
+	// Constants are the user-defined constants that contain paths,
+	// for example, 'ROOT' for "require ROOT . '/lib.php'".
+	Constants map[string]string
+	// Autoload describes the classes autoloaded by composer, may be nil.
+	Autoload *Autoload
+}
+
+// NewConfig creates a new empty config.
+func NewConfig() *Config {
+	return &Config{
+		Constants: map[string]string{},
+	}
+}
+
+// ParseRoots parses a comma-separated list of include roots.
+func ParseRoots(value string) []string {
+	var roots []string
+	for _, root := range strings.Split(value, ",") {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+
+		roots = append(roots, filepath.Clean(root))
+	}
+	return roots
+}
+
+// ParseConstants parses a comma-separated list of constants
+// in the 'NAME=path' format.
+func ParseConstants(value string) (map[string]string, error) {
+	constants := map[string]string{}
+
+	for _, constant := range strings.Split(value, ",") {
+		constant = strings.TrimSpace(constant)
+		if constant == "" {
+			continue
+		}
+
+		sep := strings.Index(constant, "=")
+		if sep <= 0 {
+			return nil, fmt.Errorf("invalid path constant '%s', the correct format is 'NAME=path'", constant)
+		}
+
+		name := strings.TrimPrefix(strings.TrimSpace(constant[:sep]), `\`)
+		constants[name] = strings.TrimSpace(constant[sep+1:])
+	}
+
+	return constants, nil
+}
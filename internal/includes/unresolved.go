@@ -0,0 +1,53 @@
+package includes
+
+import (
+	"sort"
+	"sync"
+)
+
+// Unresolved is an include whose path could not be resolved.
+type Unresolved struct {
+	Filename string
+	Line     int
+	Expr     string
+}
+
+// UnresolvedList is a thread-safe list of unresolved includes.
+type UnresolvedList struct {
+	mtx   sync.Mutex
+	items []Unresolved
+}
+
+// Add adds an unresolved include to the list.
+func (l *UnresolvedList) Add(item Unresolved) {
+	l.mtx.Lock()
+	l.items = append(l.items, item)
+	l.mtx.Unlock()
+}
+
+// Sorted returns the unique unresolved includes sorted by their position.
+func (l *UnresolvedList) Sorted() []Unresolved {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	// The same include can be handled both at the
+	// root level and in the block context.
+	seen := make(map[Unresolved]struct{}, len(l.items))
+	items := make([]Unresolved, 0, len(l.items))
+	for _, item := range l.items {
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Filename != items[j].Filename {
+			return items[i].Filename < items[j].Filename
+		}
+		return items[i].Line < items[j].Line
+	})
+
+	return items
+}
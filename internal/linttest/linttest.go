@@ -13,8 +13,14 @@ import (
 	"github.com/VKCOM/noverify/src/workspace"
 
 	cmdp "github.com/vkcom/nocolor/cmd"
+	"github.com/vkcom/nocolor/internal/checkers"
+	"github.com/vkcom/nocolor/internal/customedges"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/entrypoints"
+	"github.com/vkcom/nocolor/internal/includes"
 	"github.com/vkcom/nocolor/internal/palette"
 	"github.com/vkcom/nocolor/internal/pipes"
+	"github.com/vkcom/nocolor/internal/symbols"
 	"github.com/vkcom/nocolor/internal/walkers"
 )
 
@@ -24,12 +30,34 @@ import (
 type Suite struct {
 	t testing.TB
 
-	Palette string
-	Files   []linttest.TestFile
-	Expect  []string
+	Palette      string
+	Edges        string
+	ExcludeEdges string
+	Dependencies bool
+
+	PreciseCycles bool
+	EntryPoints   string
+
+	// MaxConcurrency is the number of the workers that check
+	// the colors, see pipes.Workers, 1 if it is not set.
+	MaxConcurrency int
+
+	IncludeRoots  []string
+	PathConstants map[string]string
+	Composer      string
+
+	Files  []linttest.TestFile
+	Expect []string
 
 	config *linter.Config
 	linter *linter.Linter
+
+	palette              *palette.Palette
+	functions            *symbols.Functions
+	classes              *symbols.Classes
+	unresolvedIncludes   []includes.Unresolved
+	unreachableFunctions []*symbols.Function
+	linterReports        []*linter.Report
 }
 
 // NewSuite returns a new linter test suite for t.
@@ -66,6 +94,19 @@ func (s *Suite) RunLinter() []*pipes.ColorReport {
 	s.t.Helper()
 
 	globalContext := walkers.NewGlobalContext(s.linter.MetaInfo())
+	globalContext.Dependencies = s.Dependencies
+
+	globalContext.Includes.Roots = s.IncludeRoots
+	if s.PathConstants != nil {
+		globalContext.Includes.Constants = s.PathConstants
+	}
+	if s.Composer != "" {
+		autoload, err := includes.ReadComposerJSON("composer.json", []byte(s.Composer))
+		if err != nil {
+			s.t.Fatalf("%v", err)
+		}
+		globalContext.Includes.Autoload = autoload
+	}
 	pal := palette.NewPalette()
 	walkers.Register(s.config, globalContext, pal, "color")
 
@@ -77,6 +118,12 @@ func (s *Suite) RunLinter() []*pipes.ColorReport {
 
 	*pal = *paletteFromFile
 
+	excludedEdges, err := edgekind.Parse(s.ExcludeEdges)
+	if err != nil {
+		s.t.Fatalf("%v", err)
+	}
+	pal.ExcludeEdges(excludedEdges)
+
 	indexing := s.linter.NewIndexingWorker(0)
 
 	shuffleFiles(s.Files)
@@ -88,6 +135,7 @@ func (s *Suite) RunLinter() []*pipes.ColorReport {
 
 	linting := s.linter.NewLintingWorker(0)
 
+	s.linterReports = nil
 	shuffleFiles(s.Files)
 	for _, f := range s.Files {
 		if f.Nolint {
@@ -97,18 +145,90 @@ func (s *Suite) RunLinter() []*pipes.ColorReport {
 			continue
 		}
 
-		parseTestFile(s.t, linting, f)
+		for _, report := range parseTestFile(s.t, linting, f) {
+			if checkers.Contains(report.CheckName) {
+				s.linterReports = append(s.linterReports, report)
+			}
+		}
+	}
+
+	if s.Edges != "" {
+		edges, err := customedges.ReadConfigFileYAML("edges.yaml", []byte(s.Edges))
+		if err != nil {
+			s.t.Fatalf("%v", err)
+		}
+
+		pipes.AddCustomEdges(globalContext.Functions, edges)
+	}
+
+	var entryPoints *entrypoints.Config
+	if s.EntryPoints != "" {
+		entryPoints, err = entrypoints.ReadConfigFileYAML("entry-points.yaml", []byte(s.EntryPoints), pal)
+		if err != nil {
+			s.t.Fatalf("%v", err)
+		}
+	}
+
+	maxConcurrency := s.MaxConcurrency
+	if maxConcurrency == 0 {
+		maxConcurrency = 1
 	}
 
 	reports := cmdp.HandleFunctions(&cmd.AppContext{
 		ParsedFlags: cmd.ParsedFlags{
-			MaxConcurrency: 1,
+			MaxConcurrency: maxConcurrency,
 		},
-	}, globalContext.Functions, pal)
+	}, globalContext.Functions, pal, cmdp.CheckOptions{
+		PreciseCycles: s.PreciseCycles,
+		EntryPoints:   entryPoints,
+	})
+
+	s.palette = pal
+	s.functions = globalContext.Functions
+	s.classes = globalContext.Classes
+	s.unresolvedIncludes = globalContext.UnresolvedIncludes.Sorted()
+	s.unreachableFunctions = nil
+	if entryPoints != nil {
+		s.unreachableFunctions = pipes.FindUnreachableFunctions(globalContext.Functions, entryPoints)
+	}
 
 	return reports
 }
 
+// ParsedPalette returns the palette with which
+// the colors were checked during the last RunLinter call.
+func (s *Suite) ParsedPalette() *palette.Palette {
+	return s.palette
+}
+
+// Functions returns the functions collected during the last RunLinter call.
+func (s *Suite) Functions() *symbols.Functions {
+	return s.functions
+}
+
+// Classes returns the classes collected during the last RunLinter call.
+func (s *Suite) Classes() *symbols.Classes {
+	return s.classes
+}
+
+// UnresolvedIncludes returns the includes that could
+// not be resolved during the last RunLinter call.
+func (s *Suite) UnresolvedIncludes() []includes.Unresolved {
+	return s.unresolvedIncludes
+}
+
+// UnreachableFunctions returns the colored functions that are not
+// reachable from any entry point during the last RunLinter call.
+func (s *Suite) UnreachableFunctions() []*symbols.Function {
+	return s.unreachableFunctions
+}
+
+// LinterReports returns the reports of the errors in the
+// tags, like 'errorColor', during the last RunLinter call.
+func (s *Suite) LinterReports() []*linter.Report {
+	return s.linterReports
+}
+
 // RunAndMatch calls Match with the results of RunLinter.
 //
 // This is a recommended way to use the Suite, but if
@@ -187,19 +307,22 @@ func shuffleFiles(files []linttest.TestFile) {
 	})
 }
 
-func parseTestFile(t testing.TB, worker *linter.Worker, f linttest.TestFile) {
+func parseTestFile(t testing.TB, worker *linter.Worker, f linttest.TestFile) []*linter.Report {
 	file := workspace.FileInfo{
 		Name:     f.Name,
 		Contents: f.Data,
 	}
 
 	var err error
+	var result linter.ParseResult
 	if worker.MetaInfo().IsIndexingComplete() {
-		_, err = worker.ParseContents(file)
+		result, err = worker.ParseContents(file)
 	} else {
 		err = worker.IndexFile(file)
 	}
 	if err != nil {
 		t.Fatalf("could not parse %s: %v", f.Name, err.Error())
 	}
+
+	return result.Reports
 }
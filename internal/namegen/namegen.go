@@ -0,0 +1,98 @@
+package namegen
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/VKCOM/noverify/src/meta"
+)
+
+// anonClassPrefix is the prefix of all synthetic anonymous class names.
+const anonClassPrefix = `\class@anonymous$`
+
+func FileFunction(filename string) string {
+	hash := md5.Sum([]byte(filename))
+	return "src$" + hex.EncodeToString(hash[:]) + "$" + filename
+}
+
+// FileFromFileFunction returns the filename of the file function.
+func FileFromFileFunction(name string) string {
+	return strings.SplitN(name, "$", 3)[2]
+}
+
+func DefaultConstructor(class string) string {
+	return Method(class, "__construct (default autogenerated)")
+}
+
+// ClassNode returns the name of the node that represents
+// the class itself in the dependencies mode.
+func ClassNode(class string) string {
+	return Method(class, "class (type node)")
+}
+
+// ClassFromClassNode returns the class name of the class node.
+func ClassFromClassNode(name string) string {
+	return strings.TrimSuffix(name, "::class (type node)")
+}
+
+// PseudoNode returns the name of the pseudo node
+// for a superglobal or a language construct.
+func PseudoNode(name string) string {
+	return "<" + name + ">"
+}
+
+// CallContext returns the name of the context node for
+// the call of the callee from the caller, for example,
+// '<in-loop>$\f$\g'.
+func CallContext(context, caller, callee string) string {
+	return "<" + context + ">$" + caller + "$" + callee
+}
+
+func Method(class, method string) string {
+	return class + "::" + method
+}
+
+func ClassFQN(state *meta.ClassParseState, class string) string {
+	return state.Namespace + `\` + class
+}
+
+// AnonClass returns a synthetic name for an anonymous class declared in
+// the passed file at the passed line, the index is the ordinal number of
+// the class among the anonymous classes of the file.
+//
+// The name looks like '\class@anonymous$file.php:12$2', where 12 is
+// the line and 2 means that it is the second anonymous class of the file.
+func AnonClass(filename string, line, index int) string {
+	return anonClassPrefix + filename + ":" + strconv.Itoa(line) + "$" + strconv.Itoa(index)
+}
+
+// IsAnonClass checks if the passed class name is a synthetic anonymous class name.
+func IsAnonClass(class string) bool {
+	return strings.HasPrefix(class, anonClassPrefix)
+}
+
+// SplitAnonClass returns the file, the line and the index
+// of the anonymous class declaration, see AnonClass.
+func SplitAnonClass(class string) (filename, line, index string) {
+	name := strings.TrimPrefix(class, anonClassPrefix)
+
+	sep := strings.LastIndex(name, "$")
+	name, index = name[:sep], name[sep+1:]
+
+	sep = strings.LastIndex(name, ":")
+	return name[:sep], name[sep+1:], index
+}
+
+func FunctionFQN(state *meta.ClassParseState, name string) string {
+	if state.Namespace != "" {
+		return state.Namespace + `\` + name
+	}
+
+	return `\` + name
+}
+
+func FromStubs(name string) bool {
+	return strings.Contains(name, "phpstorm-stubs")
+}
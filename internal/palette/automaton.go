@@ -0,0 +1,85 @@
+package palette
+
+// Automaton matches chains of colors against all rules of the palette.
+//
+// A rule matches a chain if the colors of the rule are a subsequence of the
+// chain, so the state of the automaton is the number of matched colors of
+// each rule. Chains with the same state match the same rules now and after
+// any continuation, so the chain search does not need to check them twice.
+type Automaton struct {
+	rulesets []*Ruleset
+
+	// offsets are the indexes of the first
+	// rule of each ruleset in the state.
+	offsets []int
+	size    int
+}
+
+// AutomatonState is the number of matched colors
+// of each rule, one byte per rule.
+type AutomatonState string
+
+// NewAutomaton compiles the rulesets of the palette into an automaton.
+func NewAutomaton(palette *Palette) *Automaton {
+	a := &Automaton{
+		rulesets: palette.Rulesets,
+		offsets:  make([]int, 0, len(palette.Rulesets)),
+	}
+
+	for _, ruleset := range palette.Rulesets {
+		a.offsets = append(a.offsets, a.size)
+		a.size += len(ruleset.Rules)
+	}
+
+	return a
+}
+
+// Start returns the state of the empty chain.
+func (a *Automaton) Start() AutomatonState {
+	return AutomatonState(make([]byte, a.size))
+}
+
+// Next returns the state of the chain continued with the passed colors.
+func (a *Automaton) Next(state AutomatonState, colors []Color) AutomatonState {
+	if len(colors) == 0 {
+		return state
+	}
+
+	next := []byte(state)
+
+	for i, ruleset := range a.rulesets {
+		for j, rule := range ruleset.Rules {
+			index := a.offsets[i] + j
+
+			for _, color := range colors {
+				matched := int(next[index])
+				if matched < len(rule.Colors) && rule.Colors[matched] == color {
+					next[index]++
+				}
+			}
+		}
+	}
+
+	return AutomatonState(next)
+}
+
+// MatchedRule returns the rule of the ruleset that decides for the chain
+// with the passed state, that is, the last rule matching the chain.
+func (a *Automaton) MatchedRule(state AutomatonState, rulesetIndex int) (*Rule, bool) {
+	rules := a.rulesets[rulesetIndex].Rules
+
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+
+		if int(state[a.offsets[rulesetIndex]+i]) == len(rule.Colors) {
+			return rule, true
+		}
+	}
+
+	return nil, false
+}
+
+// Rulesets returns the number of rulesets in the automaton.
+func (a *Automaton) Rulesets() int {
+	return len(a.rulesets)
+}
@@ -108,3 +108,74 @@ func (masks ColorMasks) Contains(color Color) bool {
 
 	return (masks[color.Index].Val&color.Val) != 0 && masks[color.Index].Index == color.Index
 }
+
+// ContainsAnyOf checks if any of the passed colors is contained in the masks.
+func (masks ColorMasks) ContainsAnyOf(colors []Color) bool {
+	for _, color := range colors {
+		if masks.Contains(color) {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns new masks with the colors of both masks.
+func (masks ColorMasks) Union(other ColorMasks) ColorMasks {
+	if len(other) > len(masks) {
+		masks, other = other, masks
+	}
+	if len(other) == 0 {
+		return masks
+	}
+
+	union := append(ColorMasks(nil), masks...)
+	for i, mask := range other {
+		union[i].Val |= mask.Val
+	}
+
+	return union
+}
+
+// Intersect returns new masks with the colors contained in
+// both masks, or nil if there are no such colors.
+func (masks ColorMasks) Intersect(other ColorMasks) ColorMasks {
+	if len(other) < len(masks) {
+		masks, other = other, masks
+	}
+
+	var intersection ColorMasks
+	for i, mask := range masks {
+		if mask.Val&other[i].Val != 0 {
+			intersection = append(ColorMasks(nil), masks...)
+			break
+		}
+	}
+	if intersection == nil {
+		return nil
+	}
+
+	for i := range intersection {
+		intersection[i].Val &= other[i].Val
+	}
+
+	return intersection
+}
+
+// Equal checks if both masks contain the same colors.
+func (masks ColorMasks) Equal(other ColorMasks) bool {
+	if len(other) > len(masks) {
+		masks, other = other, masks
+	}
+
+	for i, mask := range masks {
+		var otherVal uint64
+		if i < len(other) {
+			otherVal = other[i].Val
+		}
+		if mask.Val != otherVal {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,6 +1,8 @@
 package palette
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -10,8 +12,14 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v2"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
 )
 
+// excludeEdgesKey is a special key of the rule that describes
+// the kinds of edges that are ignored for the ruleset.
+const excludeEdgesKey = "exclude-edges"
+
 type ConfigRule map[string]string
 
 // Config is a structure for storing a palette of colors as a config.
@@ -62,7 +70,20 @@ ruleset description:
 In .yaml syntax, it's a map from a string key (description) to a list (rules)`, path)
 	}
 
-	return parsePaletteRaw(path, config)
+	pal, err := parsePaletteRaw(path, config)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(data)
+	pal.Hash = hex.EncodeToString(hash[:])
+
+	// The palette file is located in the root folder of the project.
+	if root, err := filepath.Abs(filepath.Dir(path)); err == nil {
+		pal.Root = root
+	}
+
+	return pal, nil
 }
 
 func parsePaletteRaw(path string, config *Config) (*Palette, error) {
@@ -81,6 +102,7 @@ func parsePaletteRaw(path string, config *Config) (*Palette, error) {
 
 	for _, group := range groups {
 		var rules []*Rule
+		var excludedEdges edgekind.Kind
 
 		for _, rule := range group.rules {
 			var colorsRaw, desc string
@@ -89,6 +111,16 @@ func parsePaletteRaw(path string, config *Config) (*Palette, error) {
 				desc = rDesc
 			}
 
+			if colorsRaw == excludeEdgesKey {
+				kind, err := edgekind.Parse(desc)
+				if err != nil {
+					return nil, fmt.Errorf("error in palette file '%s' in ruleset '%s': %v", path, group.name, err)
+				}
+
+				excludedEdges |= kind
+				continue
+			}
+
 			colors := strings.Split(colorsRaw, " ")
 			colorsNums := make([]Color, 0, len(colors))
 
@@ -106,7 +138,10 @@ func parsePaletteRaw(path string, config *Config) (*Palette, error) {
 			rules = append(rules, NewRule(colorsNums, desc))
 		}
 
-		pal.AddRuleset(NewRuleset(rules...))
+		ruleset := NewRuleset(group.name, rules...)
+		ruleset.ExcludedEdges = excludedEdges
+
+		pal.AddRuleset(ruleset)
 	}
 
 	return pal, nil
@@ -1,17 +1,42 @@
 package palette
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
 )
 
 // Ruleset is a group of rules, where order is important.
 // Typically, it looks like one error rule and some
 // "exceptions" — more specific color chains with no error
-type Ruleset []*Rule
+type Ruleset struct {
+	// Name is the description of the ruleset from the palette file.
+	Name  string
+	Rules []*Rule
+
+	// ExcludedEdges are the kinds of edges that
+	// are ignored when checking the ruleset.
+	ExcludedEdges edgekind.Kind
+}
 
 // NewRuleset creates a new Ruleset.
-func NewRuleset(rules ...*Rule) Ruleset {
-	return append(Ruleset(nil), rules...)
+func NewRuleset(name string, rules ...*Rule) *Ruleset {
+	return &Ruleset{
+		Name:  name,
+		Rules: append([]*Rule(nil), rules...),
+	}
+}
+
+// InScope checks if the ruleset is listed in the scoped remover.
+func (r *Ruleset) InScope(scope RemoverScope) bool {
+	for _, name := range scope.Rulesets {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Rule are representation of human-written rule:
@@ -73,8 +98,16 @@ func (r *Rule) String(palette *Palette) string {
 // Palette is a group of rulesets.
 // All colors are stored as Color struct, not as strings.
 type Palette struct {
-	Rulesets          []Ruleset
+	Rulesets          []*Ruleset
 	ColorNamesMapping map[string]Color
+
+	// Hash is the SHA-256 of the palette file,
+	// it identifies the palette in the reports.
+	Hash string
+
+	// Root is the directory of the palette file, that is, the root of the
+	// project, the paths that identify the violations are relative to it.
+	Root string
 }
 
 // NewPalette creates a new Palette.
@@ -87,10 +120,127 @@ func NewPalette() *Palette {
 	}
 }
 
-func (p *Palette) AddRuleset(ruleset Ruleset) {
+func (p *Palette) AddRuleset(ruleset *Ruleset) {
 	p.Rulesets = append(p.Rulesets, ruleset)
 }
 
+// ExcludeEdges excludes the passed kinds of edges for all rulesets.
+func (p *Palette) ExcludeEdges(kind edgekind.Kind) {
+	for _, ruleset := range p.Rulesets {
+		ruleset.ExcludedEdges |= kind
+	}
+}
+
+// WithRulesets returns a palette with the same colors
+// as the current one, but with the passed rulesets.
+func (p *Palette) WithRulesets(rulesets []*Ruleset) *Palette {
+	return &Palette{
+		Rulesets:          rulesets,
+		ColorNamesMapping: p.ColorNamesMapping,
+		Hash:              p.Hash,
+		Root:              p.Root,
+	}
+}
+
+// SplitByExcludedEdges splits the palette into palettes, all rulesets
+// of each of which ignore the same kinds of edges, since such rulesets
+// are checked on the same graph.
+func (p *Palette) SplitByExcludedEdges() []*Palette {
+	var kinds []edgekind.Kind
+	rulesets := map[edgekind.Kind][]*Ruleset{}
+
+	for _, ruleset := range p.Rulesets {
+		if _, ok := rulesets[ruleset.ExcludedEdges]; !ok {
+			kinds = append(kinds, ruleset.ExcludedEdges)
+		}
+		rulesets[ruleset.ExcludedEdges] = append(rulesets[ruleset.ExcludedEdges], ruleset)
+	}
+
+	palettes := make([]*Palette, 0, len(kinds))
+	for _, kind := range kinds {
+		palettes = append(palettes, p.WithRulesets(rulesets[kind]))
+	}
+
+	return palettes
+}
+
+// removerScopePrefix is the prefix of the scoped remover
+// color, like 'remover:db' or 'remover:{fast,slow}'.
+const removerScopePrefix = "remover:"
+
+// ParseRemoverScope returns the colors and rulesets listed in the scoped
+// remover color, ok is false if the color is not a scoped remover.
+//
+// If a name is both a color and a ruleset, it is interpreted as a color.
+func (p *Palette) ParseRemoverScope(colorName string) (scope RemoverScope, ok bool, err error) {
+	if !strings.HasPrefix(colorName, removerScopePrefix) {
+		return scope, false, nil
+	}
+
+	value := strings.TrimPrefix(colorName, removerScopePrefix)
+	if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
+		value = value[1 : len(value)-1]
+	}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+
+		switch {
+		case name == "":
+			return scope, true, fmt.Errorf("empty color or ruleset name in '%s'", colorName)
+		case name == "transparent" || name == "remover":
+			return scope, true, fmt.Errorf("special color '%s' cannot be used in '%s'", name, colorName)
+		case p.ColorExists(name):
+			scope.Colors = append(scope.Colors, p.GetColorByName(name))
+		case p.rulesetExists(name):
+			scope.Rulesets = append(scope.Rulesets, name)
+		default:
+			return scope, true, fmt.Errorf("'%s' in '%s' is neither a color nor a ruleset of the palette", name, colorName)
+		}
+	}
+
+	return scope, true, nil
+}
+
+func (p *Palette) rulesetExists(name string) bool {
+	for _, ruleset := range p.Rulesets {
+		if ruleset.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRuleOrRuleset checks if the palette has a ruleset with the
+// name or a rule with the colors, like 'highload no-highload'.
+func (p *Palette) HasRuleOrRuleset(name string) bool {
+	if p.rulesetExists(name) {
+		return true
+	}
+
+	for _, ruleset := range p.Rulesets {
+		for _, rule := range ruleset.Rules {
+			if rule.String(p) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RulesetOf returns the ruleset that contains the rule, or nil
+// if the rule is not from the palette.
+func (p *Palette) RulesetOf(rule *Rule) *Ruleset {
+	for _, ruleset := range p.Rulesets {
+		for _, r := range ruleset.Rules {
+			if r == rule {
+				return ruleset
+			}
+		}
+	}
+	return nil
+}
+
 func (p *Palette) ColorExists(colorName string) bool {
 	_, ok := p.ColorNamesMapping[colorName]
 	return ok
@@ -137,6 +287,45 @@ func (p *Palette) GetNameByColor(needColor Color) string {
 // parsing above each function (order is important).
 type ColorContainer struct {
 	Colors []Color
+
+	// RemoverScope is the colors and rulesets from the
+	// scoped removers, like '@color remover:db'.
+	RemoverScope RemoverScope
+}
+
+// RemoverScope describes the colors and rulesets listed in the scoped removers.
+//
+// The functions with the listed colors are not reachable through the function
+// with the scoped remover, and for the listed rulesets it is removed from the
+// call graph, like with '@color remover'.
+type RemoverScope struct {
+	Colors   []Color
+	Rulesets []string
+}
+
+// Empty checks if nothing is listed in the scope.
+func (s RemoverScope) Empty() bool {
+	return len(s.Colors) == 0 && len(s.Rulesets) == 0
+}
+
+// Merge returns the scope with both the current and the passed colors and rulesets.
+func (s RemoverScope) Merge(other RemoverScope) RemoverScope {
+	return RemoverScope{
+		Colors:   append(append([]Color(nil), s.Colors...), other.Colors...),
+		Rulesets: append(append([]string(nil), s.Rulesets...), other.Rulesets...),
+	}
+}
+
+// ContainsAnyOf checks if any of the passed colors is listed in the scope.
+func (s RemoverScope) ContainsAnyOf(colors []Color) bool {
+	for _, color := range colors {
+		for _, scopeColor := range s.Colors {
+			if color == scopeColor {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (c *ColorContainer) Add(color Color) {
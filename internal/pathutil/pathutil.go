@@ -0,0 +1,42 @@
+package pathutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Relative returns the path relative to the working directory, if possible.
+//
+// The empty path stays empty, so that it is not shown as the working directory.
+func Relative(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+
+	return RelativeTo(wd, path)
+}
+
+// RelativeTo returns the path relative to the root, if possible,
+// the relative paths are taken as relative to the working directory.
+//
+// If the root is empty, the path is returned as is.
+func RelativeTo(root, path string) string {
+	if root == "" {
+		return filepath.ToSlash(path)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		relPath, err := filepath.Rel(root, absPath)
+		if err == nil {
+			path = relPath
+		}
+	}
+
+	return filepath.ToSlash(path)
+}
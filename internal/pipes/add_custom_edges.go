@@ -0,0 +1,38 @@
+package pipes
+
+import (
+	"github.com/vkcom/nocolor/internal/customedges"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// AddCustomEdges adds edges described in the config to the functions.
+//
+// Such edges describe calls that cannot be resolved statically, for example,
+// calls of event listeners from the event dispatcher, so they must be added
+// before the functions are converted to the graph nodes.
+func AddCustomEdges(funcs *symbols.Functions, config *customedges.Config) {
+	for _, rule := range config.Rules {
+		var callers, callees []*symbols.Function
+
+		for _, fun := range funcs.Functions {
+			if rule.MatchFrom(fun) {
+				callers = append(callers, fun)
+			}
+			if rule.MatchTo(fun) {
+				callees = append(callees, fun)
+			}
+		}
+
+		for _, caller := range callers {
+			for _, callee := range callees {
+				if caller == callee {
+					continue
+				}
+
+				caller.Called.Add(callee, edgekind.Custom)
+				callee.CalledBy.Add(caller, edgekind.Custom)
+			}
+		}
+	}
+}
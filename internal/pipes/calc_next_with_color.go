@@ -1,20 +1,38 @@
 package pipes
 
 import (
+	"sort"
+
 	"github.com/vkcom/nocolor/internal/callgraph"
 	"github.com/vkcom/nocolor/internal/palette"
 )
 
-type visitedMap map[*callgraph.Node]int
+// visitedMap stores the marks of the nodes by their IDs.
+type visitedMap []int
+
+// maxPreciseComponentSize is the maximum number of functions in a recursive
+// component, the paths inside which are explored in the precise mode. Such
+// exploration is quadratic, so larger components are handled approximately.
+const maxPreciseComponentSize = 1000
 
 // CalcNextWithColor function to calculate node.NextWithColors for every function.
 // We'll use it for perform dfs only for colored nodes of a call graph.
 //
+// If preciseCycles is true, the real paths inside recursive components
+// are explored instead of assuming that everything is reachable.
+//
 // Note: this precalculation is needed, because dfs for a whole call graph is too
 // heavy on a large code base.
-func CalcNextWithColor(graph *callgraph.Graph) {
+func CalcNextWithColor(graph *callgraph.Graph, preciseCycles bool) {
 	nodes := graph.Functions
-	nodesCount := len(nodes)
+	nodesCount := graph.Size()
+
+	// The nodes can be shared by several graphs that are
+	// processed one by one, see SplitByScopedRemovers.
+	for _, fun := range nodes {
+		fun.NextWithColors = nil
+		fun.NextScopes = nil
+	}
 
 	visited := make(visitedMap, nodesCount)
 	topSorted := make(callgraph.Nodes, 0, nodesCount)
@@ -32,7 +50,7 @@ func CalcNextWithColor(graph *callgraph.Graph) {
 
 	for i := len(topSorted) - 1; i >= 0; i-- {
 		node := topSorted[i]
-		if visited[node] == 1 {
+		if visited[node.ID] != 0 {
 			continue
 		}
 
@@ -42,29 +60,70 @@ func CalcNextWithColor(graph *callgraph.Graph) {
 		currentColor++
 		componentDFS(node, graph, currentColor, visited, &wasColors, &component, &edges)
 
+		if preciseCycles && len(component) > 1 && len(component) <= maxPreciseComponentSize {
+			eachComponentPrecise(component, graph, currentColor, visited)
+			continue
+		}
+
 		eachComponent(component, edges)
 	}
 }
 
+// nextColored is the set of the reachable colored functions with the colors
+// of the scoped removers through which they are reached, nil if the function
+// is reached without passing through them.
+type nextColored map[*callgraph.Node]palette.ColorMasks
+
+// add adds the function reached through the scoped removers with the passed
+// colors, if it is reached by several paths, only the colors of the scoped
+// removers that are on all of them are kept.
+func (n nextColored) add(node *callgraph.Node, scope palette.ColorMasks) bool {
+	prev, ok := n[node]
+	if !ok {
+		n[node] = scope
+		return true
+	}
+	if prev == nil {
+		return false
+	}
+
+	scope = prev.Intersect(scope)
+	if scope.Equal(prev) {
+		return false
+	}
+
+	n[node] = scope
+	return true
+}
+
+// addNextOf adds the NextWithColors of the function.
+func (n nextColored) addNextOf(fun *callgraph.Node) {
+	if fun.NextWithColors == nil {
+		return
+	}
+
+	for i, node := range *fun.NextWithColors {
+		n.add(node, fun.NextScope(i))
+	}
+}
+
 func eachComponent(component, edges callgraph.Nodes) {
-	nextColoredUniq := make(map[*callgraph.Node]struct{}, 10)
+	nextColoredUniq := make(nextColored, 10)
 
 	// If an edge is colored, append this edge.
 	// If not, append NextWithColors from this edge.
 	for _, fun := range edges {
 		if fun.Function.HasColors() {
-			nextColoredUniq[fun] = struct{}{}
-		} else if fun.NextWithColors != nil {
-			for _, node := range *fun.NextWithColors {
-				nextColoredUniq[node] = struct{}{}
-			}
+			nextColoredUniq.add(fun, nil)
+		} else {
+			nextColoredUniq.addNextOf(fun)
 		}
 	}
 
 	// For a recursive bunch of functions (e.g. f1 -> f2 -> f1) assume that
 	// "every functions is reachable from any of them" — so, append all colored
 	// functions from a recurse. This is not true in all cases, but enough for
-	// practical usage.
+	// practical usage. See eachComponentPrecise for the precise mode.
 	//
 	// Also, to reduce a number of permutations for next colored path searching,
 	// choose only one representative of each color.
@@ -81,39 +140,189 @@ func eachComponent(component, edges callgraph.Nodes) {
 			for _, color := range node.Function.Colors.Colors {
 				if !added.Contains(color) {
 					added = added.Add(color)
-					nextColoredUniq[node] = struct{}{}
+					nextColoredUniq.add(node, nil)
 				}
 			}
 		}
 	}
 
-	if len(nextColoredUniq) != 0 {
-		nextWithColors := make(callgraph.Nodes, 0, len(nextColoredUniq))
-		for node := range nextColoredUniq {
-			nextWithColors = append(nextWithColors, node)
+	nextWithColors, nextScopes := nextWithColorsOf(palette.RemoverScope{}, nextColoredUniq)
+	if nextWithColors == nil {
+		return
+	}
+
+	// Since every function is assumed to be reachable without passing
+	// through the scoped removers, their colors only apply to the removers.
+	for _, node := range component {
+		if hasScopedRemover(node) {
+			node.NextWithColors, node.NextScopes = nextWithColorsOf(node.Function.Colors.RemoverScope, nextColoredUniq)
+			continue
 		}
 
-		for _, node := range component {
-			node.NextWithColors = &nextWithColors
+		node.NextWithColors, node.NextScopes = nextWithColors, nextScopes
+	}
+}
+
+// eachComponentPrecise calculates NextWithColors separately for each function
+// of a recursive component, following the real paths inside it.
+//
+// The path stops at the first colored function, as in acyclic graphs, and
+// for functions outside the component, their NextWithColors are used, since
+// they have already been calculated.
+//
+// The path also stops at the function with a scoped remover, like
+// '@color remover:db', the colored functions reachable through it are added
+// afterwards, except the ones with the colors listed in the remover.
+func eachComponentPrecise(component callgraph.Nodes, graph *callgraph.Graph, color int, visited visitedMap) {
+	reached := make([]bool, graph.Size())
+	var reachedList []int32
+
+	reachable := make(map[*callgraph.Node]nextColored, len(component))
+	throughRemovers := make(map[*callgraph.Node]callgraph.Nodes)
+
+	for _, start := range component {
+		nextColoredUniq := make(nextColored, 10)
+		stack := callgraph.Nodes{start}
+
+		for _, id := range reachedList {
+			reached[id] = false
 		}
+		reached[start.ID] = true
+		reachedList = append(reachedList[:0], start.ID)
+
+		for len(stack) != 0 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			for _, id := range graph.NextIDs(node.ID) {
+				if reached[id] || graph.Removed(id) {
+					continue
+				}
+				reached[id] = true
+				reachedList = append(reachedList, id)
+
+				next := graph.Nodes[id]
+
+				if next.Function.HasColors() {
+					nextColoredUniq.add(next, nil)
+					continue
+				}
+
+				if visited[id] != color {
+					nextColoredUniq.addNextOf(next)
+					continue
+				}
+
+				if hasScopedRemover(next) {
+					throughRemovers[start] = append(throughRemovers[start], next)
+					continue
+				}
+
+				stack = append(stack, next)
+			}
+		}
+
+		reachable[start] = nextColoredUniq
+	}
+
+	// The removers can reach each other, so the colored
+	// functions are added through them until nothing changes.
+	for changed := true; changed; {
+		changed = false
+
+		for _, start := range component {
+			for _, remover := range throughRemovers[start] {
+				scope := remover.Function.Colors.RemoverScope
+				scopeMasks := palette.NewColorMasks(scope.Colors)
+
+				for node, nodeScope := range reachable[remover] {
+					if scope.ContainsAnyOf(node.Function.Colors.Colors) {
+						continue
+					}
+
+					if reachable[start].add(node, nodeScope.Union(scopeMasks)) {
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	for _, start := range component {
+		start.NextWithColors, start.NextScopes = nextWithColorsOf(start.Function.Colors.RemoverScope, reachable[start])
+	}
+}
+
+// hasScopedRemover checks if the function has a scoped remover that lists
+// colors, the removers that list only rulesets are already erased from the graph.
+func hasScopedRemover(node *callgraph.Node) bool {
+	return len(node.Function.Colors.RemoverScope.Colors) != 0
+}
+
+// nextWithColorsOf returns the NextWithColors and the NextScopes from the set
+// of the reachable colored functions, or nil if it is empty.
+//
+// Functions with the colors listed in the scope of the scoped remover, like
+// '@color remover:db', are not reachable through the function with it, as
+// well as through the colored functions reachable through it.
+func nextWithColorsOf(removerScope palette.RemoverScope, nextColoredUniq nextColored) (*callgraph.Nodes, []palette.ColorMasks) {
+	nextWithColors := make(callgraph.Nodes, 0, len(nextColoredUniq))
+	for node := range nextColoredUniq {
+		if removerScope.ContainsAnyOf(node.Function.Colors.Colors) {
+			continue
+		}
+
+		nextWithColors = append(nextWithColors, node)
+	}
+
+	if len(nextWithColors) == 0 {
+		return nil, nil
 	}
+
+	// The chains are searched in this order, so it must not depend on the
+	// order of the map, otherwise the shown chains differ from run to run.
+	sort.Slice(nextWithColors, func(i, j int) bool {
+		return nextWithColors[i].Function.Name < nextWithColors[j].Function.Name
+	})
+
+	removerMasks := palette.NewColorMasks(removerScope.Colors)
+	if len(removerScope.Colors) == 0 {
+		removerMasks = nil
+	}
+
+	var nextScopes []palette.ColorMasks
+	for i, node := range nextWithColors {
+		scope := nextColoredUniq[node].Union(removerMasks)
+		if scope == nil {
+			continue
+		}
+
+		if nextScopes == nil {
+			nextScopes = make([]palette.ColorMasks, len(nextWithColors))
+		}
+		nextScopes[i] = scope
+	}
+
+	return &nextWithColors, nextScopes
 }
 
 func topSortedDFS(fun *callgraph.Node, graph *callgraph.Graph, visited visitedMap, topSorted *callgraph.Nodes) {
-	if visited[fun] == 1 {
+	if visited[fun.ID] == 1 {
 		return
 	}
-	visited[fun] = 1
+	visited[fun.ID] = 1
 
-	for _, prev := range graph.RevGraph[fun] {
-		topSortedDFS(prev, graph, visited, topSorted)
+	for _, id := range graph.PrevIDs(fun.ID) {
+		if !graph.Removed(id) {
+			topSortedDFS(graph.Nodes[id], graph, visited, topSorted)
+		}
 	}
 
 	*topSorted = append(*topSorted, fun)
 }
 
 func componentDFS(fun *callgraph.Node, graph *callgraph.Graph, color int, visited visitedMap, wasColors *[]int, component, edges *callgraph.Nodes) {
-	otherColor := visited[fun]
+	otherColor := visited[fun.ID]
 	if otherColor == color {
 		return
 	}
@@ -126,10 +335,12 @@ func componentDFS(fun *callgraph.Node, graph *callgraph.Graph, color int, visite
 		return
 	}
 
-	visited[fun] = color
+	visited[fun.ID] = color
 	*component = append(*component, fun)
 
-	for _, next := range graph.Graph[fun] {
-		componentDFS(next, graph, color, visited, wasColors, component, edges)
+	for _, id := range graph.NextIDs(fun.ID) {
+		if !graph.Removed(id) {
+			componentDFS(graph.Nodes[id], graph, color, visited, wasColors, component, edges)
+		}
 	}
 }
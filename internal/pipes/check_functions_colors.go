@@ -1,35 +1,142 @@
 package pipes
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"runtime"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/i582/cfmt/cmd/cfmt"
 
 	"github.com/vkcom/nocolor/internal/callgraph"
+	"github.com/vkcom/nocolor/internal/edgekind"
 	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
 )
 
+// minParallelGraphSize is the minimum number of functions in a graph
+// for which the roots are checked in parallel.
+const minParallelGraphSize = 1000
+
 // CheckColorsInGraph check all palette rules like "api has-curl" or
 // "messages-module messages-internals".
 //
-// Start from the root, make dfs expanding NextWithColors
+// Start from the roots, see FindRootNodes and FindEntryNodes, make dfs expanding NextWithColors
 // and check all rules for each dfs chain.
 //
 // These chains are left-to-right, e.g. colored f1 -> f2 -> f3,
 // we find and check f1, then f1 -> f2, then f1 -> f2 -> f3.
-func CheckColorsInGraph(graph *callgraph.Graph, palette *palette.Palette) []*ColorReport {
-	roots := FindRootNodes(graph)
+//
+// Chains that end in the same function and match the rules in the same way
+// (see palette.Automaton) have the same continuations, so the continuations
+// that break the rules are found once and reused for other such chains.
+//
+// Every violation is reported once, with the shortest chain found for it.
+// Violations are the same if they break the same rule and have the same
+// first and last colored functions.
+//
+// In large graphs, the roots are checked by the passed workers that are free,
+// the function must be called by one of them, see Workers.Run. The reports of
+// each root do not depend on the other roots, and they are merged in the order
+// of the roots, so the result is the same for any number of workers.
+func CheckColorsInGraph(graph *callgraph.Graph, palette *palette.Palette, roots callgraph.Nodes, workers *Workers) []*ColorReport {
+	maxWorkers := runtime.GOMAXPROCS(0)
+	if graph.Size() < minParallelGraphSize {
+		maxWorkers = 1
+	}
+	if maxWorkers > len(roots) {
+		maxWorkers = len(roots)
+	}
+
+	found := newFoundContinuations()
+	paths := newFoundPaths()
+	merger := newReportsMerger(len(roots))
+
+	nextRoot := int64(-1)
+	check := func() {
+		checker := newCheckerFunctionsColors(graph, palette, found, paths)
+		for {
+			index := int(atomic.AddInt64(&nextRoot, 1))
+			if index >= len(roots) {
+				return
+			}
+
+			merger.add(index, checker.checkRoot(roots[index]))
+		}
+	}
+
+	// The calling goroutine is one of the workers, the others
+	// are taken only if they are not busy with other graphs.
+	var wg sync.WaitGroup
+	for i := 1; i < maxWorkers && workers.tryAcquire(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer workers.release()
+
+			check()
+		}()
+	}
+
+	check()
+	wg.Wait()
+
+	return merger.reports
+}
+
+// reportsMerger merges the reports of the roots in the order of the roots,
+// no matter in which order the roots are checked.
+type reportsMerger struct {
+	mtx sync.Mutex
+
+	rootsReports [][]*ColorReport
+	checked      []bool
+	merged       int
+
+	shownErrors map[violationKey]int
+	reports     []*ColorReport
+}
+
+func newReportsMerger(roots int) *reportsMerger {
+	return &reportsMerger{
+		rootsReports: make([][]*ColorReport, roots),
+		checked:      make([]bool, roots),
+		shownErrors:  map[violationKey]int{},
+	}
+}
+
+// add adds the reports of the checked root and merges the reports
+// of all roots checked before the first root that is not checked yet.
+func (m *reportsMerger) add(index int, reports []*ColorReport) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.rootsReports[index] = reports
+	m.checked[index] = true
 
-	var reports []*ColorReport
-	checker := newCheckerFunctionsColors(graph, palette)
-	for _, root := range roots {
-		callstack := callgraph.NewCallstackOfColoredFunctions()
-		reports = append(reports,
-			checker.checkFuncDFS(callstack, graph, root)...,
-		)
+	for m.merged < len(m.checked) && m.checked[m.merged] {
+		m.mergeRoot(m.rootsReports[m.merged])
+		m.rootsReports[m.merged] = nil
+		m.merged++
 	}
+}
 
-	return reports
+func (m *reportsMerger) mergeRoot(reports []*ColorReport) {
+	for _, report := range reports {
+		if index, ok := m.shownErrors[report.key]; ok {
+			if report.chainSize < m.reports[index].chainSize {
+				m.reports[index] = report
+			}
+			continue
+		}
+
+		m.shownErrors[report.key] = len(m.reports)
+		m.reports = append(m.reports, report)
+	}
 }
 
 // FindRootNodes finds the nodes with the minimum number
@@ -41,8 +148,13 @@ func FindRootNodes(graph *callgraph.Graph) callgraph.Nodes {
 		return nil
 	}
 
+	// The roots are ordered by name, so the order of
+	// the reports does not depend on the order of the nodes.
 	sort.Slice(funcs, func(i, j int) bool {
-		return len(funcs[i].Prev) < len(funcs[j].Prev)
+		if len(funcs[i].Prev) != len(funcs[j].Prev) {
+			return len(funcs[i].Prev) < len(funcs[j].Prev)
+		}
+		return funcs[i].Function.Name < funcs[j].Function.Name
 	})
 
 	minIndex := 0
@@ -61,129 +173,438 @@ func FindRootNodes(graph *callgraph.Graph) callgraph.Nodes {
 	return funcs[:minIndex+1]
 }
 
-const maxShownErrorsCount = 10
+// maxCallstackSize is the maximum number of colored functions in a chain.
+const maxCallstackSize = 50
+
+// foundContinuations are the continuations of the chains found for
+// the functions with the certain states of the rules.
+//
+// They are shared by the workers that check the same graph.
+type foundContinuations struct {
+	mtx           sync.RWMutex
+	continuations map[chainState]*continuations
+}
+
+func newFoundContinuations() *foundContinuations {
+	return &foundContinuations{
+		continuations: map[chainState]*continuations{},
+	}
+}
+
+func (f *foundContinuations) get(key chainState) (*continuations, bool) {
+	f.mtx.RLock()
+	found, ok := f.continuations[key]
+	f.mtx.RUnlock()
+	return found, ok
+}
+
+func (f *foundContinuations) add(key chainState, found *continuations) {
+	f.mtx.Lock()
+	f.continuations[key] = found
+	f.mtx.Unlock()
+}
+
+// foundPaths are the paths between the adjacent colored functions
+// of the chains, found for the reports.
+//
+// They are shared by the workers that check the same graph.
+type foundPaths struct {
+	mtx   sync.RWMutex
+	paths map[[2]*callgraph.Node]callgraph.Nodes
+}
+
+func newFoundPaths() *foundPaths {
+	return &foundPaths{
+		paths: map[[2]*callgraph.Node]callgraph.Nodes{},
+	}
+}
+
+func (f *foundPaths) get(from, to *callgraph.Node) (callgraph.Nodes, bool) {
+	f.mtx.RLock()
+	path, ok := f.paths[[2]*callgraph.Node{from, to}]
+	f.mtx.RUnlock()
+	return path, ok
+}
+
+func (f *foundPaths) add(from, to *callgraph.Node, path callgraph.Nodes) {
+	f.mtx.Lock()
+	f.paths[[2]*callgraph.Node{from, to}] = path
+	f.mtx.Unlock()
+}
+
+// violationKey identifies a violation, that is, a rule broken by
+// the chains from the first to the last colored function.
+type violationKey struct {
+	rule  *palette.Rule
+	first *callgraph.Node
+	last  *callgraph.Node
+}
 
 type checkerFunctionsColors struct {
 	callGraph *callgraph.Graph
 	palette   *palette.Palette
+	automaton *palette.Automaton
+
+	found *foundContinuations
+	paths *foundPaths
 
-	// shownErrors is used to prevent duplicate errors from being shown.
-	shownErrors map[string]struct{}
+	// shownErrors are the indexes of the reports of the violations,
+	// it is used to prevent duplicate errors from being shown.
+	shownErrors map[violationKey]int
+
+	// visitedLevel is the levels of the nodes in the bfs by their IDs,
+	// -1 for the nodes that are not visited.
+	visitedLevel []int32
+
+	reports []*ColorReport
+}
+
+// chainState is the last function of the chain, the state of the rules for
+// the chain and the colors of the scoped removers the chain passes through.
+type chainState struct {
+	node  *callgraph.Node
+	state palette.AutomatonState
+	scope string
+}
+
+// continuations describes the continuations of the chains that end in the
+// function, found by the dfs from it.
+type continuations struct {
+	// broken are the continuations that break the rules,
+	// they start with the function itself.
+	broken []brokenChain
+
+	// blocked are the functions that were not expanded, since they
+	// were already in the callstack, so the continuations are complete
+	// only for the callstacks that contain all of them.
+	blocked map[*callgraph.Node]struct{}
+
+	// truncated is true if some chains were not expanded because of
+	// maxCallstackSize, so the continuations are complete only for
+	// the callstacks of at least the same size.
+	truncated     bool
+	callstackSize int
 }
 
-func newCheckerFunctionsColors(callGraph *callgraph.Graph, palette *palette.Palette) *checkerFunctionsColors {
+type brokenChain struct {
+	rule  *palette.Rule
+	chain callgraph.Nodes
+}
+
+func newCheckerFunctionsColors(callGraph *callgraph.Graph, pal *palette.Palette, found *foundContinuations, paths *foundPaths) *checkerFunctionsColors {
+	visitedLevel := make([]int32, callGraph.Size())
+	for i := range visitedLevel {
+		visitedLevel[i] = -1
+	}
+
 	return &checkerFunctionsColors{
-		callGraph:   callGraph,
-		palette:     palette,
-		shownErrors: map[string]struct{}{},
+		callGraph:    callGraph,
+		palette:      pal,
+		automaton:    palette.NewAutomaton(pal),
+		found:        found,
+		paths:        paths,
+		visitedLevel: visitedLevel,
 	}
 }
 
-func (c *checkerFunctionsColors) checkFuncDFS(callstack *callgraph.CallstackOfColoredFunctions, graph *callgraph.Graph, node *callgraph.Node) (reports []*ColorReport) {
+// checkRoot checks the chains from the root and returns the reports.
+func (c *checkerFunctionsColors) checkRoot(root *callgraph.Node) []*ColorReport {
+	c.shownErrors = map[violationKey]int{}
+	c.reports = nil
+
+	callstack := callgraph.NewCallstackOfColoredFunctions()
+	c.checkFuncDFS(callstack, c.automaton.Start(), nil, root)
+
+	return c.reports
+}
+
+// checkFuncDFS checks the chains continued with the function, the scope is
+// the colors of the scoped removers the chain passes through, the functions
+// with them are not reachable from the function.
+func (c *checkerFunctionsColors) checkFuncDFS(callstack *callgraph.CallstackOfColoredFunctions, state palette.AutomatonState, scope palette.ColorMasks, node *callgraph.Node) *continuations {
+	state = c.automaton.Next(state, node.Function.Colors.Colors)
+	key := chainState{node: node, state: state, scope: scopeKey(scope)}
+
+	// If the continuations found earlier pass through the functions of the
+	// callstack, the dfs finds other ones for it, so they are searched
+	// directly, and the ones found earlier are kept for other callstacks.
+	cached, ok := c.found.get(key)
+	reusable := ok && cached.completeFor(callstack, node)
+	if reusable && !cached.passThrough(callstack) {
+		c.reportContinuations(callstack, cached)
+		return cached
+	}
+
 	callstack.Append(node)
 
-	wasAnyError := false
+	found := &continuations{
+		blocked:       map[*callgraph.Node]struct{}{},
+		callstackSize: callstack.Size(),
+	}
+
+	for i := 0; i < c.automaton.Rulesets(); i++ {
+		rule, ok := c.automaton.MatchedRule(state, i)
+		if !ok || !rule.IsError() {
+			continue
+		}
+
+		// The rule is broken by the chains to each function with its last
+		// color, the functions in between are reported by the chains to them.
+		if !node.Function.Colors.Contains(rule.Colors[len(rule.Colors)-1]) {
+			continue
+		}
+
+		c.report(callstack, rule)
+		found.broken = append(found.broken, brokenChain{rule: rule, chain: callgraph.Nodes{node}})
+	}
 
-	for _, ruleset := range c.palette.Rulesets {
-		for i := len(ruleset) - 1; i >= 0; i-- {
-			rule := ruleset[i]
+	if node.NextWithColors != nil {
+		// Continuations with the same key are reported as the
+		// same violation, so only the shortest one is kept.
+		uniqueBroken := make(map[brokenChainKey]int, len(found.broken))
+		for i, broken := range found.broken {
+			uniqueBroken[broken.key()] = i
+		}
 
-			if !matchRule(callstack, rule) {
+		for i, next := range *node.NextWithColors {
+			nextScope := scope.Union(node.NextScope(i))
+			if nextScope.ContainsAnyOf(next.Function.Colors.Colors) {
 				continue
 			}
 
-			if rule.IsError() {
-				report := c.errorOnRuleBroken(callstack, rule)
-				if report != nil {
-					reports = append(reports, report)
+			if callstack.Size() >= maxCallstackSize {
+				found.truncated = true
+				continue
+			}
+			if callstack.Contains(next) {
+				found.blocked[next] = struct{}{}
+				continue
+			}
+
+			nextFound := c.checkFuncDFS(callstack, state, nextScope, next)
+
+			for _, broken := range nextFound.broken {
+				chain := make(callgraph.Nodes, 0, len(broken.chain)+1)
+				chain = append(chain, node)
+				chain = append(chain, broken.chain...)
+
+				broken = brokenChain{rule: broken.rule, chain: chain}
+				if index, ok := uniqueBroken[broken.key()]; ok {
+					if len(chain) < len(found.broken[index].chain) {
+						found.broken[index] = broken
+					}
+					continue
 				}
-				wasAnyError = true
+
+				uniqueBroken[broken.key()] = len(found.broken)
+				found.broken = append(found.broken, broken)
 			}
-			break
+			for blocked := range nextFound.blocked {
+				found.blocked[blocked] = struct{}{}
+			}
+			found.truncated = found.truncated || nextFound.truncated
 		}
 	}
 
-	if !wasAnyError && node.NextWithColors != nil {
-		for _, next := range *node.NextWithColors {
-			if callstack.Size() < 50 && !callstack.Contains(next) {
-				reps := c.checkFuncDFS(callstack, graph, next)
-				reports = append(reports, reps...)
-			}
+	callstack.PopBack()
+
+	if !reusable {
+		c.found.add(key, found)
+	}
+	return found
+}
+
+// scopeKey returns the key of the colors of the scoped removers for chainState.
+func scopeKey(scope palette.ColorMasks) string {
+	var key strings.Builder
+	for i, mask := range scope {
+		if mask.Val != 0 {
+			key.WriteString(strconv.Itoa(i))
+			key.WriteByte(':')
+			key.WriteString(strconv.FormatUint(mask.Val, 16))
+			key.WriteByte(',')
 		}
 	}
+	return key.String()
+}
 
-	callstack.PopBack()
-	return reports
+// brokenChainKey identifies the violation of the broken chain
+// for any callstack before it, see violationKey.
+type brokenChainKey struct {
+	rule *palette.Rule
+	// first is the first function of the violation, nil if it is
+	// in the callstack before the chain, then left is the number of
+	// the colors of the rule that are matched in the callstack.
+	first *callgraph.Node
+	left  int
+	last  *callgraph.Node
+}
+
+func (b brokenChain) key() brokenChainKey {
+	key := brokenChainKey{
+		rule: b.rule,
+		last: b.chain[len(b.chain)-1],
+	}
+
+	start, left := matchFromEnd(b.chain, b.rule)
+	if left == 0 {
+		key.first = b.chain[start]
+	}
+	key.left = left
+
+	return key
+}
+
+// matchFromEnd matches the colors of the rule with the functions from
+// the end, it returns the index of the latest function from which the
+// functions still match the rule, or the number of colors of the rule
+// that are left unmatched.
+func matchFromEnd(nodes callgraph.Nodes, rule *palette.Rule) (start int, left int) {
+	left = len(rule.Colors)
+
+	for i := len(nodes) - 1; i >= 0; i-- {
+		for left > 0 && nodes[i].Function.Colors.Contains(rule.Colors[left-1]) {
+			left--
+		}
+
+		if left == 0 {
+			return i, 0
+		}
+	}
+
+	return -1, left
 }
 
-func matchRule(callstack *callgraph.CallstackOfColoredFunctions, rule *palette.Rule) bool {
-	matchMasks := callstack.ColorsMasks
-	if len(matchMasks) == 0 || !rule.ContainsIn(matchMasks) {
+// completeFor checks if the continuations found earlier are the same as the
+// dfs would find for the passed callstack continued with the passed function.
+func (f *continuations) completeFor(callstack *callgraph.CallstackOfColoredFunctions, node *callgraph.Node) bool {
+	if f.truncated && callstack.Size()+1 < f.callstackSize {
 		return false
 	}
 
-	return matchTwoVectors(rule.Colors, callstack.ColorsChain)
+	for blocked := range f.blocked {
+		if blocked != node && !callstack.Contains(blocked) {
+			return false
+		}
+	}
+
+	return true
 }
 
-func matchTwoVectors(ruleChain, actualChain []palette.Color) bool {
-	ruleIndex := len(ruleChain) - 1
-	actualIndex := len(actualChain) - 1
+// passThrough checks if any of the continuations that break
+// the rules passes through the functions of the callstack.
+func (f *continuations) passThrough(callstack *callgraph.CallstackOfColoredFunctions) bool {
+	for _, broken := range f.broken {
+		if containsAny(callstack, broken.chain) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportContinuations reports the continuations found earlier that break
+// the rules, as if the dfs had found them for the passed callstack.
+func (c *checkerFunctionsColors) reportContinuations(callstack *callgraph.CallstackOfColoredFunctions, found *continuations) {
+	for _, broken := range found.broken {
+		if callstack.Size()+len(broken.chain) > maxCallstackSize {
+			continue
+		}
+
+		for _, node := range broken.chain {
+			callstack.Append(node)
+		}
 
-	for {
-		rightmostMatched := ruleChain[ruleIndex] == actualChain[actualIndex]
+		c.report(callstack, broken.rule)
 
-		if rightmostMatched {
-			if ruleIndex == 0 {
-				return true
-			}
-			if actualIndex == 0 {
-				return false
-			}
-			ruleIndex--
-			actualIndex--
-		} else {
-			if actualIndex == 0 {
-				return false
-			}
-			actualIndex--
+		for range broken.chain {
+			callstack.PopBack()
+		}
+	}
+}
+
+func (c *checkerFunctionsColors) report(callstack *callgraph.CallstackOfColoredFunctions, rule *palette.Rule) {
+	vector := callstack.AsVector() // f1, f2, f3 — all of them are colored, and their chain breaks the rule.
+
+	// The violation starts from the latest function from which the chain
+	// is still broken, for example, for "f1@api -> f2@api -> f3@curl",
+	// it is f2, since the violation of f1 is reported for the chain to f2.
+	first, left := matchFromEnd(vector, rule)
+	if left != 0 {
+		first = 0
+	}
+	last := len(vector) - 1
+
+	key := violationKey{rule: rule, first: vector[first], last: vector[last]}
+
+	// If the rule is broken by the function itself,
+	// the whole chain to it is shown.
+	if first == last {
+		first = 0
+	}
+	chain := vector[first:]
+
+	if index, ok := c.shownErrors[key]; ok {
+		if len(chain) < c.reports[index].chainSize {
+			c.reports[index] = c.errorOnRuleBroken(chain, rule, key)
 		}
+		return
 	}
+
+	c.shownErrors[key] = len(c.reports)
+	c.reports = append(c.reports, c.errorOnRuleBroken(chain, rule, key))
+}
+
+func containsAny(callstack *callgraph.CallstackOfColoredFunctions, nodes callgraph.Nodes) bool {
+	for _, node := range nodes {
+		if callstack.Contains(node) {
+			return true
+		}
+	}
+	return false
 }
 
 // On error (colored chain breaks some rule), we want to find an actual chain of calling.
-// findCallstackBetweenTwoFunctionsBFS is launched only on error, that's why we don't care
-// about performance and just use bfs.
+// findCallstackBetweenTwoFunctionsBFS is launched only on error, that's why we just use bfs.
 func (c *checkerFunctionsColors) findCallstackBetweenTwoFunctionsBFS(from, target *callgraph.Node, shouldntAppear map[*callgraph.Node]struct{}) callgraph.Nodes {
-	visitedLevel := map[*callgraph.Node]int{}
+	visitedLevel := c.visitedLevel
 	var bfsQueue callgraph.Nodes
 
+	// All visited nodes are added to the queue, so the levels are reset
+	// only for them, not to fill the whole slice for each search.
+	defer func() {
+		for _, node := range bfsQueue {
+			visitedLevel[node.ID] = -1
+		}
+	}()
+
 	bfsQueue = append(bfsQueue, from)
-	visitedLevel[from] = 0
+	visitedLevel[from.ID] = 0
 
-	for len(bfsQueue) != 0 {
-		cur := bfsQueue[0]
-		bfsQueue = bfsQueue[1:]
-		nextLevel := visitedLevel[cur] + 1
+	for head := 0; head < len(bfsQueue); head++ {
+		cur := bfsQueue[head]
+		nextLevel := visitedLevel[cur.ID] + 1
 
 		if cur == target {
 			break
 		}
 
-		for _, called := range c.callGraph.Graph[cur] {
-			if _, ok := visitedLevel[called]; ok {
+		for _, id := range c.callGraph.NextIDs(cur.ID) {
+			if visitedLevel[id] != -1 || c.callGraph.Removed(id) {
 				continue
 			}
+
+			called := c.callGraph.Nodes[id]
 			if _, ok := shouldntAppear[called]; ok {
 				continue
 			}
 
-			visitedLevel[called] = nextLevel
+			visitedLevel[id] = nextLevel
 			bfsQueue = append(bfsQueue, called)
 		}
 	}
 
 	// If couldn't find, just return [from, target].
-	if _, ok := visitedLevel[target]; !ok {
+	if visitedLevel[target.ID] == -1 {
 		return callgraph.Nodes{from, target}
 	}
 
@@ -191,12 +612,11 @@ func (c *checkerFunctionsColors) findCallstackBetweenTwoFunctionsBFS(from, targe
 	callstack = append(callstack, target)
 
 	for cur := target; cur != from; {
-		prevLevel := visitedLevel[cur] - 1
-		for _, callee := range c.callGraph.RevGraph[cur] {
-			level, has := visitedLevel[callee]
-			if has && level == prevLevel {
-				callstack = append(callstack, callee)
-				cur = callee
+		prevLevel := visitedLevel[cur.ID] - 1
+		for _, id := range c.callGraph.PrevIDs(cur.ID) {
+			if visitedLevel[id] == prevLevel {
+				callstack = append(callstack, c.callGraph.Nodes[id])
+				cur = c.callGraph.Nodes[id]
 				break
 			}
 		}
@@ -210,29 +630,17 @@ func (c *checkerFunctionsColors) findCallstackBetweenTwoFunctionsBFS(from, targe
 	return revCallstack
 }
 
-func (c *checkerFunctionsColors) errorOnRuleBroken(callstack *callgraph.CallstackOfColoredFunctions, rule *palette.Rule) *ColorReport {
-	if len(c.shownErrors) > maxShownErrorsCount {
-		return nil
-	}
-
-	var fullCallstack callgraph.Nodes // Will be: src_main -> ... -> f1 -> ... -> f2 -> ... -> f3.
-	vector := callstack.AsVector()    // f1, f2, f3 — all of them are colored, and their chain breaks the rule.
-	for i := 0; i < callstack.Size()-1; i++ {
+func (c *checkerFunctionsColors) errorOnRuleBroken(vector callgraph.Nodes, rule *palette.Rule, key violationKey) *ColorReport {
+	var fullCallstack callgraph.Nodes // Will be: f1 -> ... -> f2 -> ... -> f3.
+	for i := 0; i < len(vector)-1; i++ {
 		cur := vector[i]
 		next := vector[i+1]
-		shouldntAppear := map[*callgraph.Node]struct{}{}
 
 		if cur.NextWithColors == nil {
 			continue
 		}
 
-		for _, exclude := range *cur.NextWithColors {
-			if exclude != next && exclude != cur {
-				shouldntAppear[exclude] = struct{}{}
-			}
-		}
-
-		nextCallstackPart := c.findCallstackBetweenTwoFunctionsBFS(cur, next, shouldntAppear)
+		nextCallstackPart := c.findCallstackBetweenTwoColoredFunctions(cur, next)
 		fullCallstack = append(fullCallstack, nextCallstackPart[:len(nextCallstackPart)-1]...)
 	}
 	fullCallstack = append(fullCallstack, vector[len(vector)-1])
@@ -261,18 +669,91 @@ func (c *checkerFunctionsColors) errorOnRuleBroken(callstack *callgraph.Callstac
 		}
 	}
 
-	if _, ok := c.shownErrors[callstackStr]; ok {
-		return nil
+	callKinds := make([]edgekind.Kind, 0, len(callChainToShow))
+	callSites := make([]symbols.CallSite, 0, len(callChainToShow))
+	callKindsStr := ""
+	callSitesStr := ""
+	for i := 0; i < len(callChainToShow)-1; i++ {
+		kind := callChainToShow[i].KindOf(callChainToShow[i+1])
+		callKinds = append(callKinds, kind)
+
+		site := callChainToShow[i].CallSiteOf(callChainToShow[i+1])
+		callSites = append(callSites, site)
+
+		if i != 0 {
+			callKindsStr += " -> "
+			callSitesStr += " -> "
+		}
+		callKindsStr += kindName(kind)
+		callSitesStr += site.String()
 	}
-	c.shownErrors[callstackStr] = struct{}{}
 
 	message := cfmt.Sprintf("{{%s}}::cyan => {{%s}}::red\n  This color rule is broken, call chain:\n%s",
 		rule.String(c.palette), rule.Error, callstackStr)
 
+	if callKindsStr != "" {
+		message += "\n  Call kinds: " + callKindsStr
+		message += "\n  Call sites: " + callSitesStr
+	}
+
 	return &ColorReport{
 		Rule:      rule,
 		CallChain: callChainToShow,
+		CallKinds: callKinds,
+		CallSites: callSites,
 		Message:   message,
 		Palette:   c.palette,
+
+		Fingerprint: fingerprint(rule, c.palette, key),
+
+		key:       key,
+		chainSize: len(vector),
+	}
+}
+
+// findCallstackBetweenTwoColoredFunctions finds the path between the
+// adjacent colored functions of the chain that does not pass through
+// other colored functions called from the first one.
+func (c *checkerFunctionsColors) findCallstackBetweenTwoColoredFunctions(cur, next *callgraph.Node) callgraph.Nodes {
+	if path, ok := c.paths.get(cur, next); ok {
+		return path
+	}
+
+	shouldntAppear := map[*callgraph.Node]struct{}{}
+	for _, exclude := range *cur.NextWithColors {
+		if exclude != next && exclude != cur {
+			shouldntAppear[exclude] = struct{}{}
+		}
+	}
+
+	path := c.findCallstackBetweenTwoFunctionsBFS(cur, next, shouldntAppear)
+	c.paths.add(cur, next, path)
+
+	return path
+}
+
+// fingerprint returns a hash of the violation that does not depend on the
+// shown chain, the lines or the working directory, so it is the same between
+// runs while the rule, its ruleset and the first and last colored functions
+// are the same, the functions are identified by their stable names.
+func fingerprint(rule *palette.Rule, pal *palette.Palette, key violationKey) string {
+	var rulesetName string
+	if ruleset := pal.RulesetOf(rule); ruleset != nil {
+		rulesetName = ruleset.Name
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{rule.String(pal), rulesetName, key.first.Function.StableName(pal.Root), key.last.Function.StableName(pal.Root)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+func kindName(kind edgekind.Kind) string {
+	if kind == edgekind.None {
+		return "unknown"
 	}
+	return kind.String()
 }
@@ -0,0 +1,72 @@
+package pipes
+
+import (
+	"encoding/xml"
+	"sort"
+
+	"github.com/vkcom/nocolor/internal/pathutil"
+)
+
+// CheckstyleReport is the root element of a Checkstyle XML file.
+type CheckstyleReport struct {
+	XMLName xml.Name          `xml:"checkstyle"`
+	Version string            `xml:"version,attr"`
+	Files   []*CheckstyleFile `xml:"file"`
+}
+
+type CheckstyleFile struct {
+	Name   string             `xml:"name,attr"`
+	Errors []*CheckstyleError `xml:"error"`
+}
+
+type CheckstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// NewCheckstyleReport converts the reports to a Checkstyle report.
+//
+// Each report is an error at the position where the chain starts, see
+// ColorReport.Position, the message of which contains the full call chain.
+// The source of the error is the rule, or the checker for the reports
+// of the collecting colors stage.
+func NewCheckstyleReport(reports []*GeneralReport) *CheckstyleReport {
+	files := map[string]*CheckstyleFile{}
+	for _, report := range reports {
+		name := pathutil.Relative(report.File)
+
+		file, ok := files[name]
+		if !ok {
+			file = &CheckstyleFile{Name: name}
+			files[name] = file
+		}
+
+		source := report.Rule
+		if report.linterReport != nil {
+			source = report.linterReport.CheckName
+		}
+
+		file.Errors = append(file.Errors, &CheckstyleError{
+			Line:     report.Line,
+			Severity: "error",
+			Message:  report.PlainText(),
+			Source:   "nocolor." + source,
+		})
+	}
+
+	checkstyle := &CheckstyleReport{Version: "4.3"}
+	for _, file := range files {
+		sort.SliceStable(file.Errors, func(i, j int) bool {
+			return file.Errors[i].Line < file.Errors[j].Line
+		})
+		checkstyle.Files = append(checkstyle.Files, file)
+	}
+
+	sort.Slice(checkstyle.Files, func(i, j int) bool {
+		return checkstyle.Files[i].Name < checkstyle.Files[j].Name
+	})
+
+	return checkstyle
+}
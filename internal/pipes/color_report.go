@@ -1,15 +1,94 @@
 package pipes
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/vkcom/nocolor/internal/callgraph"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/namegen"
 	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
 )
 
 // ColorReport is a structure for storing color mixing error information.
 type ColorReport struct {
 	Rule      *palette.Rule
 	CallChain callgraph.Nodes
+	// CallKinds[i] is the kinds of the call of
+	// CallChain[i+1] from CallChain[i].
+	CallKinds []edgekind.Kind
+	// CallSites[i] is the position of the call of
+	// CallChain[i+1] in CallChain[i].
+	CallSites []symbols.CallSite
 	Message   string
 
 	Palette *palette.Palette
+
+	// Fingerprint is a stable hash of the violation, it depends only on the
+	// rule and the names of the first and last colored functions of the chain.
+	Fingerprint string
+
+	// key identifies the violation, the reports are deduplicated by it.
+	key violationKey
+	// chainSize is the number of colored functions in the chain,
+	// the report with the shortest chain is shown for the violation.
+	chainSize int
+}
+
+// PlainText returns the message of the report without highlighting.
+func (r *ColorReport) PlainText() string {
+	var chain, kinds, sites []string
+	for _, node := range r.CallChain {
+		chain = append(chain, r.nodeName(node))
+	}
+	for i := range r.CallKinds {
+		kinds = append(kinds, kindName(r.CallKinds[i]))
+		sites = append(sites, r.CallSites[i].String())
+	}
+
+	text := fmt.Sprintf("%s => %s\n  This color rule is broken, call chain:\n%s",
+		r.Rule.String(r.Palette), r.Rule.Error, strings.Join(chain, " -> "))
+
+	if len(kinds) != 0 {
+		text += "\n  Call kinds: " + strings.Join(kinds, " -> ")
+		text += "\n  Call sites: " + strings.Join(sites, " -> ")
+	}
+
+	return text
+}
+
+// Position returns the position at which the chain starts.
+//
+// The file scope has no position, so for it, the position is
+// its file and the line of the first call of the chain.
+func (r *ColorReport) Position() (filename string, line int) {
+	first := r.CallChain[0].Function
+	if first.Pos.Filename != "" {
+		return first.Pos.Filename, int(first.Pos.Line)
+	}
+
+	if first.Type == symbols.MainFunc {
+		filename = namegen.FileFromFileFunction(first.Name)
+	}
+	if len(r.CallSites) != 0 && !r.CallSites[0].Empty() {
+		if filename == "" {
+			filename = r.CallSites[0].Filename
+		}
+		line = r.CallSites[0].Line
+	}
+
+	return filename, line
+}
+
+// ColoredEnds returns the names of the first and last colored functions of
+// the violation, which, along with the rule, identify it, see Fingerprint.
+func (r *ColorReport) ColoredEnds() (first, last string) {
+	return r.key.first.Function.StableName(r.Palette.Root), r.key.last.Function.StableName(r.Palette.Root)
+}
+
+// nodeName returns the name of the function of the chain
+// along with its colors that are in the rule.
+func (r *ColorReport) nodeName(node *callgraph.Node) string {
+	return node.Function.HumanReadableName() + node.Function.Colors.String(r.Palette, r.Rule.Masks)
 }
@@ -1,6 +1,9 @@
 package pipes
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/vkcom/nocolor/internal/callgraph"
 	"github.com/vkcom/nocolor/internal/palette"
 )
@@ -15,10 +18,80 @@ import (
 func EraseNodesWithRemoverColor(graph *callgraph.Graph) {
 	removerColor := palette.NewColor(palette.SpecialColorRemover, 0)
 
-	for _, fun := range graph.Functions {
+	for _, fun := range graph.Nodes {
 		if fun.Function.HasColors() && fun.Function.Colors.Contains(removerColor) {
 			graph.Remove(fun)
-			graph.Functions.Remove(fun)
+			graph.Functions = graph.Functions.Remove(fun)
+		}
+	}
+}
+
+// ScopedGraph is a call graph with the palette
+// of the rulesets that are checked on it.
+type ScopedGraph struct {
+	Graph   *callgraph.Graph
+	Palette *palette.Palette
+}
+
+// SplitByScopedRemovers returns the graphs on which the rulesets of the palette
+// are checked.
+//
+// Unlike '@color remover', a scoped remover that lists a ruleset, like
+// '@color remover:ssr', removes a function only for this ruleset, other rulesets
+// still check the chains that pass through it. So the rulesets are grouped by
+// the scoped removers that affect them, and each group gets its own copy of the
+// graph. The colors listed in the scoped removers are handled in CalcNextWithColor.
+func SplitByScopedRemovers(graph *callgraph.Graph, pal *palette.Palette) []ScopedGraph {
+	var removers callgraph.Nodes
+	for _, fun := range graph.Functions {
+		if len(fun.Function.Colors.RemoverScope.Rulesets) != 0 {
+			removers = append(removers, fun)
+		}
+	}
+
+	if len(removers) == 0 {
+		return []ScopedGraph{{Graph: graph, Palette: pal}}
+	}
+
+	var keys []string
+	rulesets := map[string][]*palette.Ruleset{}
+	removed := map[string]callgraph.Nodes{}
+
+	for _, ruleset := range pal.Rulesets {
+		var key strings.Builder
+		var nodes callgraph.Nodes
+
+		for i, remover := range removers {
+			if ruleset.InScope(remover.Function.Colors.RemoverScope) {
+				key.WriteString(strconv.Itoa(i))
+				key.WriteByte(',')
+				nodes = append(nodes, remover)
+			}
+		}
+
+		if _, ok := rulesets[key.String()]; !ok {
+			keys = append(keys, key.String())
+			removed[key.String()] = nodes
+		}
+		rulesets[key.String()] = append(rulesets[key.String()], ruleset)
+	}
+
+	graphs := make([]ScopedGraph, 0, len(keys))
+	for _, key := range keys {
+		scopedGraph := graph
+		if len(removed[key]) != 0 {
+			scopedGraph = graph.Copy()
+			for _, fun := range removed[key] {
+				scopedGraph.Remove(fun)
+				scopedGraph.Functions = scopedGraph.Functions.Remove(fun)
+			}
 		}
+
+		graphs = append(graphs, ScopedGraph{
+			Graph:   scopedGraph,
+			Palette: pal.WithRulesets(rulesets[key]),
+		})
 	}
+
+	return graphs
 }
@@ -0,0 +1,88 @@
+package pipes
+
+import (
+	"sort"
+
+	"github.com/vkcom/nocolor/internal/callgraph"
+	"github.com/vkcom/nocolor/internal/entrypoints"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// FindEntryNodes finds the nodes of the entry points described in the config.
+//
+// Unlike FindRootNodes, the chains are searched only from these nodes,
+// so the graphs without entry points are not checked at all.
+func FindEntryNodes(graph *callgraph.Graph, config *entrypoints.Config) callgraph.Nodes {
+	var entries callgraph.Nodes
+	for _, node := range graph.Functions {
+		if config.IsEntryPoint(node.Function) {
+			entries = append(entries, node)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Function.Name < entries[j].Function.Name
+	})
+
+	return entries
+}
+
+// FindUnreachableFunctions returns the colored functions that cannot
+// be reached from any entry point described in the config, sorted by name.
+//
+// Chains with such functions are not checked, since the search starts
+// only from the entry points. Functions with the '@color remover' color
+// break the connectivity, so nothing is reached through them.
+func FindUnreachableFunctions(funcs *symbols.Functions, config *entrypoints.Config) []*symbols.Function {
+	removerColor := palette.NewColor(palette.SpecialColorRemover, 0)
+	isRemover := func(fun *symbols.Function) bool {
+		return fun.HasColors() && fun.Colors.Contains(removerColor)
+	}
+
+	reached := make(map[*symbols.Function]struct{}, len(funcs.Functions))
+	var queue []*symbols.Function
+
+	for _, fun := range funcs.Functions {
+		if config.IsEntryPoint(fun) && !isRemover(fun) {
+			reached[fun] = struct{}{}
+			queue = append(queue, fun)
+		}
+	}
+
+	for len(queue) != 0 {
+		fun := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		for _, called := range fun.Called.Edges {
+			if _, ok := reached[called.Function]; ok || isRemover(called.Function) {
+				continue
+			}
+
+			reached[called.Function] = struct{}{}
+			queue = append(queue, called.Function)
+		}
+	}
+
+	var unreachable []*symbols.Function
+	for _, fun := range funcs.Functions {
+		// Pseudo and context nodes are colored implicitly,
+		// they are unreachable only if their callers are.
+		if fun.Type == symbols.PseudoNode || fun.Type == symbols.ContextNode {
+			continue
+		}
+		if !fun.HasColors() || isRemover(fun) {
+			continue
+		}
+
+		if _, ok := reached[fun]; !ok {
+			unreachable = append(unreachable, fun)
+		}
+	}
+
+	sort.Slice(unreachable, func(i, j int) bool {
+		return unreachable[i].Name < unreachable[j].Name
+	})
+
+	return unreachable
+}
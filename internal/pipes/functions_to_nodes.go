@@ -1,24 +1,63 @@
 package pipes
 
 import (
+	"sort"
+
 	"github.com/vkcom/nocolor/internal/callgraph"
+	"github.com/vkcom/nocolor/internal/edgekind"
 	"github.com/vkcom/nocolor/internal/symbols"
 )
 
 // FunctionsToNodes is a function that converts passed functions into
 // a set of nodes that represent those functions.
-func FunctionsToNodes(funcs *symbols.Functions) callgraph.Nodes {
+//
+// Edges all kinds of which are contained in excluded are skipped,
+// and the excluded kinds are removed from the kinds of other edges.
+//
+// The IDs of the nodes are their indexes in the returned slice,
+// until the nodes are split into graphs by NodesToGraphs.
+//
+// Functions and edges are stored in maps, so they are converted
+// in the order of their names, otherwise the order of the nodes
+// and, therefore, the shown call chains differ from run to run.
+func FunctionsToNodes(funcs *symbols.Functions, excluded edgekind.Kind) callgraph.Nodes {
 	nodes := make(callgraph.Nodes, 0, funcs.Len())
 	visited := make(map[*symbols.Function]*callgraph.Node, funcs.Len())
 
-	for _, fun := range funcs.Functions {
-		nodes = append(nodes, functionToNode(fun, visited))
+	names := make([]string, 0, funcs.Len())
+	for name := range funcs.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		nodes = append(nodes, functionToNode(funcs.Functions[name], excluded, visited))
+	}
+
+	// Nodes that are reachable only through edges, like context
+	// nodes, are not in the functions, so they are added at the end.
+	inNodes := make(map[*callgraph.Node]struct{}, len(nodes))
+	for _, node := range nodes {
+		inNodes[node] = struct{}{}
+	}
+	onlyThroughEdges := len(nodes)
+	for _, node := range visited {
+		if _, ok := inNodes[node]; !ok {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes[onlyThroughEdges:], func(i, j int) bool {
+		return nodes[onlyThroughEdges+i].Function.Name < nodes[onlyThroughEdges+j].Function.Name
+	})
+
+	for i, node := range nodes {
+		node.ID = int32(i)
 	}
 
 	return nodes
 }
 
-func functionToNode(fun *symbols.Function, visited map[*symbols.Function]*callgraph.Node) *callgraph.Node {
+func functionToNode(fun *symbols.Function, excluded edgekind.Kind, visited map[*symbols.Function]*callgraph.Node) *callgraph.Node {
 	if node, ok := visited[fun]; ok {
 		return node
 	}
@@ -28,13 +67,37 @@ func functionToNode(fun *symbols.Function, visited map[*symbols.Function]*callgr
 
 	node.Function = fun
 
-	for _, called := range fun.Called.Functions {
-		node.Next = append(node.Next, functionToNode(called, visited))
+	for _, called := range sortedEdges(fun.Called) {
+		kind := called.Kind &^ excluded
+		if kind == edgekind.None {
+			continue
+		}
+
+		node.Next = append(node.Next, functionToNode(called.Function, excluded, visited))
+		node.NextKinds = append(node.NextKinds, kind)
+		node.NextCallSites = append(node.NextCallSites, called.CallSite)
 	}
 
-	for _, calledBy := range fun.CalledBy.Functions {
-		node.Prev = append(node.Prev, functionToNode(calledBy, visited))
+	for _, calledBy := range sortedEdges(fun.CalledBy) {
+		if calledBy.Kind&^excluded == edgekind.None {
+			continue
+		}
+
+		node.Prev = append(node.Prev, functionToNode(calledBy.Function, excluded, visited))
 	}
 
 	return &node
 }
+
+func sortedEdges(edges *symbols.Edges) []*symbols.Edge {
+	sorted := make([]*symbols.Edge, 0, len(edges.Edges))
+	for _, edge := range edges.Edges {
+		sorted = append(sorted, edge)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Function.Name < sorted[j].Function.Name
+	})
+
+	return sorted
+}
@@ -1,11 +1,12 @@
 package pipes
 
 import (
-	"os"
-	"path/filepath"
+	"fmt"
 
 	"github.com/VKCOM/noverify/src/linter"
 	"github.com/i582/cfmt/cmd/cfmt"
+
+	"github.com/vkcom/nocolor/internal/pathutil"
 )
 
 type GeneralReport struct {
@@ -13,12 +14,15 @@ type GeneralReport struct {
 	colorReport  *ColorReport
 	fullMessage  string
 
-	Rule      string   `json:"rule"`
-	CallChain []string `json:"call-chain"`
-	Message   string   `json:"message"`
-	Context   string   `json:"context"`
-	File      string   `json:"file"`
-	Line      int      `json:"line"`
+	Rule        string   `json:"rule"`
+	CallChain   []string `json:"call-chain"`
+	CallKinds   []string `json:"call-kinds,omitempty"`
+	CallSites   []string `json:"call-sites,omitempty"`
+	Message     string   `json:"message"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Context     string   `json:"context"`
+	File        string   `json:"file"`
+	Line        int      `json:"line"`
 }
 
 func NewGeneralReportFromLinterReport(r *linter.Report) *GeneralReport {
@@ -37,15 +41,23 @@ func NewGeneralReportFromColorReport(r *ColorReport) *GeneralReport {
 		fullMessage: r.Message,
 		Rule:        r.Rule.String(r.Palette),
 		Message:     r.Rule.Error,
+		Fingerprint: r.Fingerprint,
 	}
 
-	first := r.CallChain[0].Function
-	gr.File = first.Pos.Filename
+	gr.File, gr.Line = r.Position()
 
 	for _, node := range r.CallChain {
 		gr.CallChain = append(gr.CallChain, node.Function.HumanReadableName())
 	}
 
+	for _, kind := range r.CallKinds {
+		gr.CallKinds = append(gr.CallKinds, kindName(kind))
+	}
+
+	for _, site := range r.CallSites {
+		gr.CallSites = append(gr.CallSites, site.String())
+	}
+
 	return gr
 }
 
@@ -53,17 +65,7 @@ func NewGeneralReportFromColorReport(r *ColorReport) *GeneralReport {
 func (r *GeneralReport) String() string {
 	if r.colorReport != nil {
 		first := r.colorReport.CallChain[0].Function
-
-		path := first.Pos.Filename
-		wd, err := os.Getwd()
-		if err == nil {
-			path, err = filepath.Rel(wd, path)
-			if err != nil {
-				path = first.Pos.Filename
-			}
-		}
-
-		path = filepath.ToSlash(path)
+		path := pathutil.Relative(r.File)
 
 		return cfmt.Sprintf(`~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 {{Error}}::red at the stage of checking colors
@@ -71,19 +73,10 @@ func (r *GeneralReport) String() string {
 
 %s
 
-`, path, first.Pos.Line, first.HumanReadableName(), r.fullMessage)
+`, path, r.Line, first.HumanReadableName(), r.fullMessage)
 	}
 
-	path := r.File
-	wd, err := os.Getwd()
-	if err == nil {
-		path, err = filepath.Rel(wd, path)
-		if err != nil {
-			path = r.File
-		}
-	}
-
-	path = filepath.ToSlash(path)
+	path := pathutil.Relative(r.File)
 
 	return cfmt.Sprintf(`~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 {{Error}}::red at the stage of collecting colors
@@ -94,3 +87,13 @@ func (r *GeneralReport) String() string {
 
 `, path, r.Line, r.Context, r.Message)
 }
+
+// PlainText returns the description of the report without highlighting,
+// for the formats in which the call chain is a part of the text.
+func (r *GeneralReport) PlainText() string {
+	if r.colorReport != nil {
+		return r.colorReport.PlainText()
+	}
+
+	return fmt.Sprintf("%s:%d\n  %s\n%s", pathutil.Relative(r.File), r.Line, r.Context, r.Message)
+}
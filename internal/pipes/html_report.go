@@ -0,0 +1,326 @@
+package pipes
+
+import (
+	// Used to embed the template of the HTML report.
+	_ "embed"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pathutil"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+//go:embed html_report.html
+var htmlReportTemplate string
+
+var htmlReport = template.Must(template.New("report").Parse(htmlReportTemplate))
+
+// Sizes of the call chain graphs in the HTML report, in pixels.
+const (
+	htmlCharWidth   = 7.5
+	htmlNodePadding = 12
+	htmlNodeHeight  = 40
+	htmlEdgeLength  = 90
+	htmlGraphMargin = 4
+)
+
+// HTMLOptions are the options of the HTML report.
+type HTMLOptions struct {
+	// Version is the version of the tool.
+	Version string
+
+	// SourceURL is the template of the links to the source code
+	// with the {file} and {line} placeholders, if empty, the links
+	// are the paths relative to the working directory.
+	SourceURL string
+}
+
+type htmlReportData struct {
+	Version     string
+	Violations  int
+	Rulesets    []*htmlRuleset
+	ColorErrors []*htmlColorError
+	Functions   []*htmlFunction
+}
+
+type htmlRuleset struct {
+	ID         string
+	Name       string
+	Violations []*htmlViolation
+}
+
+type htmlViolation struct {
+	Rule        string
+	Message     string
+	Fingerprint string
+	Graph       *htmlGraph
+	Hops        []*htmlHop
+}
+
+type htmlHop struct {
+	Name string
+	Kind string
+	Link htmlLink
+}
+
+type htmlColorError struct {
+	Message string
+	Context string
+	Link    htmlLink
+}
+
+type htmlFunction struct {
+	Name   string
+	Colors []*htmlColor
+	Link   htmlLink
+}
+
+type htmlColor struct {
+	Name string
+	Fill string
+}
+
+type htmlLink struct {
+	Text string
+	URL  string
+}
+
+type htmlGraph struct {
+	Width  int
+	Height int
+	Nodes  []*htmlNode
+	Edges  []*htmlEdge
+}
+
+type htmlNode struct {
+	X, Y, Width, Height  int
+	TextX, NameY, ColorY int
+
+	Name   string
+	Colors string
+	Fill   string
+	Link   htmlLink
+}
+
+type htmlEdge struct {
+	X1, X2, Y int
+	LabelX    int
+	LabelY    int
+	Label     string
+}
+
+// WriteHTMLReport writes the reports as a standalone HTML page without
+// external assets, so it can be opened from the artifacts of the CI.
+//
+// The violations are grouped by rulesets, the call chain of each of them
+// is drawn as a graph. The page also has a searchable table of all colored
+// functions, since it is the common question what color a function has.
+func WriteHTMLReport(w io.Writer, reports []*GeneralReport, pal *palette.Palette, funcs *symbols.Functions, opts HTMLOptions) error {
+	data := &htmlReportData{Version: opts.Version}
+
+	rulesets := make(map[*palette.Ruleset]*htmlRuleset, len(pal.Rulesets))
+	for i, ruleset := range pal.Rulesets {
+		r := &htmlRuleset{ID: "ruleset-" + strconv.Itoa(i), Name: ruleset.Name}
+		rulesets[ruleset] = r
+		data.Rulesets = append(data.Rulesets, r)
+	}
+
+	for _, report := range reports {
+		if report.colorReport == nil {
+			data.ColorErrors = append(data.ColorErrors, &htmlColorError{
+				Message: report.Message,
+				Context: report.Context,
+				Link:    newHTMLLink(report.File, report.Line, opts.SourceURL),
+			})
+			continue
+		}
+
+		ruleset := rulesets[pal.RulesetOf(report.colorReport.Rule)]
+		if ruleset == nil {
+			continue
+		}
+
+		ruleset.Violations = append(ruleset.Violations, newHTMLViolation(report.colorReport, opts.SourceURL))
+		data.Violations++
+	}
+
+	if funcs != nil {
+		data.Functions = newHTMLFunctions(funcs, pal, opts.SourceURL)
+	}
+
+	return htmlReport.Execute(w, data)
+}
+
+func newHTMLViolation(r *ColorReport, sourceURL string) *htmlViolation {
+	violation := &htmlViolation{
+		Rule:        r.Rule.String(r.Palette),
+		Message:     r.Rule.Error,
+		Fingerprint: r.Fingerprint,
+		Graph:       &htmlGraph{Height: htmlNodeHeight + 2*htmlGraphMargin},
+	}
+
+	x := htmlGraphMargin
+	for i, node := range r.CallChain {
+		fun := node.Function
+
+		hop := &htmlHop{
+			Name: r.nodeName(node),
+			Link: newHTMLLink(fun.Pos.Filename, int(fun.Pos.Line), sourceURL),
+		}
+		if i != 0 {
+			hop.Kind = kindName(r.CallKinds[i-1])
+
+			site := r.CallSites[i-1]
+			if !site.Empty() {
+				hop.Link = newHTMLLink(site.Filename, site.Line, sourceURL)
+			}
+		}
+		violation.Hops = append(violation.Hops, hop)
+
+		name := fun.HumanReadableName()
+		colors := fun.Colors.String(r.Palette, r.Rule.Masks)
+
+		width := int(float64(maxInt(len(name), len(colors)))*htmlCharWidth) + 2*htmlNodePadding
+		violation.Graph.Nodes = append(violation.Graph.Nodes, &htmlNode{
+			X:      x,
+			Y:      htmlGraphMargin,
+			Width:  width,
+			Height: htmlNodeHeight,
+			TextX:  x + htmlNodePadding,
+			NameY:  htmlGraphMargin + htmlNodeHeight/2 - 3,
+			ColorY: htmlGraphMargin + htmlNodeHeight/2 + 12,
+			Name:   name,
+			Colors: colors,
+			Fill:   nodeFill(fun, r.Palette, r.Rule.Masks),
+			Link:   newHTMLLink(fun.Pos.Filename, int(fun.Pos.Line), sourceURL),
+		})
+		x += width
+
+		if i != len(r.CallChain)-1 {
+			violation.Graph.Edges = append(violation.Graph.Edges, &htmlEdge{
+				X1:     x,
+				X2:     x + htmlEdgeLength,
+				Y:      htmlGraphMargin + htmlNodeHeight/2,
+				LabelX: x + htmlEdgeLength/2,
+				LabelY: htmlGraphMargin + htmlNodeHeight/2 - 6,
+				Label:  kindName(r.CallKinds[i]),
+			})
+			x += htmlEdgeLength
+		}
+	}
+	violation.Graph.Width = x + htmlGraphMargin
+
+	return violation
+}
+
+func newHTMLFunctions(funcs *symbols.Functions, pal *palette.Palette, sourceURL string) []*htmlFunction {
+	var functions []*htmlFunction
+	for _, fun := range funcs.Functions {
+		// Pseudo and context nodes are colored implicitly.
+		if fun.Type == symbols.PseudoNode || fun.Type == symbols.ContextNode || !fun.HasColors() {
+			continue
+		}
+
+		function := &htmlFunction{
+			Name: fun.HumanReadableName(),
+			Link: newHTMLLink(fun.Pos.Filename, int(fun.Pos.Line), sourceURL),
+		}
+		for _, color := range fun.Colors.Colors {
+			name := pal.GetNameByColor(color)
+			function.Colors = append(function.Colors, &htmlColor{Name: name, Fill: ColorFill(name)})
+		}
+
+		functions = append(functions, function)
+	}
+
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].Name < functions[j].Name
+	})
+
+	return functions
+}
+
+// nodeFill returns the fill of the node of the graph, which is the
+// fill of the first color of the function that is in the rule.
+func nodeFill(fun *symbols.Function, pal *palette.Palette, masks palette.ColorMasks) string {
+	for _, color := range fun.Colors.Colors {
+		if masks.Contains(color) {
+			return ColorFill(pal.GetNameByColor(color))
+		}
+	}
+
+	return "#ffffff"
+}
+
+// ColorFill returns a light fill for the color, which is
+// the same for the color name in all reports.
+func ColorFill(name string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+
+	return lightColor(float64(hash.Sum32() % 360))
+}
+
+// lightColor returns the color with the passed hue, 70% saturation
+// and 85% lightness in the '#rrggbb' format.
+func lightColor(hue float64) string {
+	const saturation, lightness = 0.7, 0.85
+
+	chroma := (1 - math.Abs(2*lightness-1)) * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g, b = chroma, x, 0
+	case hue < 120:
+		r, g, b = x, chroma, 0
+	case hue < 180:
+		r, g, b = 0, chroma, x
+	case hue < 240:
+		r, g, b = 0, x, chroma
+	case hue < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	m := lightness - chroma/2
+	channel := func(v float64) int {
+		return int(math.Round((v + m) * 255))
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", channel(r), channel(g), channel(b))
+}
+
+func newHTMLLink(filename string, line int, sourceURL string) htmlLink {
+	if filename == "" {
+		return htmlLink{}
+	}
+
+	path := pathutil.Relative(filename)
+	link := htmlLink{
+		Text: path + ":" + strconv.Itoa(line),
+		URL:  path,
+	}
+
+	if sourceURL != "" {
+		link.URL = strings.NewReplacer("{file}", path, "{line}", strconv.Itoa(line)).Replace(sourceURL)
+	}
+
+	return link
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,187 @@
+package pipes
+
+import (
+	// Used to embed the schema of the JSON report.
+	_ "embed"
+
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pathutil"
+)
+
+// JSONSchemaVersion is the version of the JSON report format. It is increased
+// on every incompatible change, the unversioned list of the reports of the
+// previous releases is version 1.
+const JSONSchemaVersion = 2
+
+// JSONReportSchema is the JSON Schema of the JSON report.
+//
+//go:embed json_report.schema.json
+var JSONReportSchema []byte
+
+// Types of the reports in the JSON report.
+const (
+	JSONViolation  = "violation"
+	JSONColorError = "color-error"
+)
+
+// JSONReport is the root object of the JSON report.
+type JSONReport struct {
+	SchemaVersion int                `json:"schema_version"`
+	Tool          JSONTool           `json:"tool"`
+	Palette       JSONPalette        `json:"palette"`
+	Summary       JSONSummary        `json:"summary"`
+	Reports       []*JSONReportEntry `json:"reports"`
+}
+
+type JSONTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type JSONPalette struct {
+	Hash string `json:"hash"`
+}
+
+type JSONSummary struct {
+	Violations  int                   `json:"violations"`
+	ColorErrors int                   `json:"color_errors"`
+	Rulesets    []*JSONRulesetSummary `json:"rulesets"`
+}
+
+type JSONRulesetSummary struct {
+	Name       string `json:"name"`
+	Violations int    `json:"violations"`
+}
+
+// JSONReportEntry is a violation of a rule, or an error
+// in a '@color' tag found at the stage of collecting colors.
+type JSONReportEntry struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+
+	// For violations only.
+	Ruleset     string     `json:"ruleset,omitempty"`
+	Rule        string     `json:"rule,omitempty"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Chain       []*JSONHop `json:"chain,omitempty"`
+
+	// For color errors only.
+	Check   string `json:"check,omitempty"`
+	Context string `json:"context,omitempty"`
+}
+
+// JSONHop is a function of the call chain of the violation.
+type JSONHop struct {
+	Name   string   `json:"name"`
+	File   string   `json:"file,omitempty"`
+	Line   int      `json:"line,omitempty"`
+	Colors []string `json:"colors"`
+
+	// Kind is the kinds of the call of the function
+	// from the previous one, empty for the first one.
+	Kind string `json:"kind,omitempty"`
+	// CallSite is the position of the call of the function
+	// in the previous one, nil for the first one or if unknown.
+	CallSite *JSONPosition `json:"call_site,omitempty"`
+}
+
+type JSONPosition struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// NewJSONReport converts the reports to the versioned JSON report.
+func NewJSONReport(reports []*GeneralReport, pal *palette.Palette, version string) *JSONReport {
+	report := &JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		Tool:          JSONTool{Name: "nocolor", Version: version},
+		Palette:       JSONPalette{Hash: pal.Hash},
+		Reports:       make([]*JSONReportEntry, 0, len(reports)),
+	}
+
+	rulesets := make(map[*palette.Ruleset]*JSONRulesetSummary, len(pal.Rulesets))
+	report.Summary.Rulesets = make([]*JSONRulesetSummary, 0, len(pal.Rulesets))
+	for _, ruleset := range pal.Rulesets {
+		summary := &JSONRulesetSummary{Name: ruleset.Name}
+		rulesets[ruleset] = summary
+		report.Summary.Rulesets = append(report.Summary.Rulesets, summary)
+	}
+
+	for _, r := range reports {
+		if r.colorReport == nil {
+			report.Summary.ColorErrors++
+			report.Reports = append(report.Reports, &JSONReportEntry{
+				Type:     JSONColorError,
+				Severity: "error",
+				Message:  r.Message,
+				File:     pathutil.Relative(r.File),
+				Line:     r.Line,
+				Check:    r.linterReport.CheckName,
+				Context:  r.Context,
+			})
+			continue
+		}
+
+		entry := newJSONViolation(r.colorReport, pal)
+		report.Summary.Violations++
+		if ruleset := pal.RulesetOf(r.colorReport.Rule); ruleset != nil {
+			rulesets[ruleset].Violations++
+		}
+
+		report.Reports = append(report.Reports, entry)
+	}
+
+	return report
+}
+
+func newJSONViolation(r *ColorReport, pal *palette.Palette) *JSONReportEntry {
+	entry := &JSONReportEntry{
+		Type:        JSONViolation,
+		Severity:    "error",
+		Message:     r.Rule.Error,
+		Rule:        r.Rule.String(pal),
+		Fingerprint: r.Fingerprint,
+	}
+
+	if ruleset := pal.RulesetOf(r.Rule); ruleset != nil {
+		entry.Ruleset = ruleset.Name
+	}
+
+	for i, node := range r.CallChain {
+		fun := node.Function
+
+		hop := &JSONHop{
+			Name:   fun.HumanReadableName(),
+			Line:   int(fun.Pos.Line),
+			Colors: []string{},
+		}
+		if fun.Pos.Filename != "" {
+			hop.File = pathutil.Relative(fun.Pos.Filename)
+		}
+		if fun.HasColors() {
+			for _, color := range fun.Colors.Colors {
+				hop.Colors = append(hop.Colors, pal.GetNameByColor(color))
+			}
+		}
+
+		if i != 0 {
+			hop.Kind = kindName(r.CallKinds[i-1])
+
+			site := r.CallSites[i-1]
+			if !site.Empty() {
+				hop.CallSite = &JSONPosition{File: pathutil.Relative(site.Filename), Line: site.Line}
+			}
+		}
+
+		entry.Chain = append(entry.Chain, hop)
+	}
+
+	filename, line := r.Position()
+	entry.File = pathutil.Relative(filename)
+	entry.Line = line
+
+	return entry
+}
@@ -0,0 +1,124 @@
+package pipes
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/vkcom/nocolor/internal/palette"
+)
+
+// JUnitReport is the root element of a JUnit XML file.
+type JUnitReport struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Suites   []*JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Cases    []*JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// NewJUnitReport converts the reports to a JUnit report.
+//
+// Each ruleset of the palette is a test suite, and each violated rule is
+// a failing test case, the body of which contains the full call chains of
+// all its violations. A ruleset without violations has a single passing
+// test case, so the CI shows which rulesets were checked. Reports of the
+// collecting colors stage are grouped in the suites of the checkers.
+func NewJUnitReport(reports []*GeneralReport, pal *palette.Palette) *JUnitReport {
+	byRule := map[*palette.Rule][]*GeneralReport{}
+	byChecker := map[string][]*GeneralReport{}
+	var checkerNames []string
+
+	for _, report := range reports {
+		if report.colorReport != nil {
+			byRule[report.colorReport.Rule] = append(byRule[report.colorReport.Rule], report)
+			continue
+		}
+
+		name := report.linterReport.CheckName
+		if _, ok := byChecker[name]; !ok {
+			checkerNames = append(checkerNames, name)
+		}
+		byChecker[name] = append(byChecker[name], report)
+	}
+
+	junit := &JUnitReport{Name: "nocolor"}
+
+	for _, ruleset := range pal.Rulesets {
+		suite := &JUnitTestSuite{Name: ruleset.Name}
+
+		for _, rule := range ruleset.Rules {
+			violations, ok := byRule[rule]
+			if !ok {
+				continue
+			}
+
+			suite.Cases = append(suite.Cases, newJUnitFailedCase(ruleset.Name, rule.String(pal), rule.Error, violations))
+		}
+
+		if len(suite.Cases) == 0 {
+			suite.Cases = append(suite.Cases, &JUnitTestCase{
+				ClassName: ruleset.Name,
+				Name:      ruleset.Name,
+			})
+		}
+
+		junit.addSuite(suite)
+	}
+
+	for _, name := range checkerNames {
+		suite := &JUnitTestSuite{Name: name}
+		suite.Cases = append(suite.Cases, newJUnitFailedCase(name, name, "errors at the stage of collecting colors", byChecker[name]))
+		junit.addSuite(suite)
+	}
+
+	return junit
+}
+
+func newJUnitFailedCase(className, name, message string, violations []*GeneralReport) *JUnitTestCase {
+	texts := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		texts = append(texts, violation.PlainText())
+	}
+
+	return &JUnitTestCase{
+		ClassName: className,
+		Name:      name,
+		Failure: &JUnitFailure{
+			Message: message,
+			Type:    name,
+			Text:    strings.Join(texts, "\n\n"),
+		},
+	}
+}
+
+func (r *JUnitReport) addSuite(suite *JUnitTestSuite) {
+	suite.Tests = len(suite.Cases)
+	for _, c := range suite.Cases {
+		if c.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	r.Suites = append(r.Suites, suite)
+	r.Tests += suite.Tests
+	r.Failures += suite.Failures
+}
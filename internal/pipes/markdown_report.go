@@ -0,0 +1,125 @@
+package pipes
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pathutil"
+)
+
+// WriteMarkdownReport writes the reports in Markdown that can be pasted
+// into a comment of a pull request, so it has no ANSI sequences.
+//
+// The report starts with a table of the number of violations of each ruleset,
+// the call chain of each violation is drawn as a Mermaid flowchart, and the
+// full text of the violation is hidden in the collapsible details.
+func WriteMarkdownReport(w io.Writer, reports []*GeneralReport, pal *palette.Palette) error {
+	var b strings.Builder
+
+	violations := make(map[*palette.Ruleset][]*ColorReport, len(pal.Rulesets))
+	var colorErrors []*GeneralReport
+	for _, report := range reports {
+		if report.colorReport == nil {
+			colorErrors = append(colorErrors, report)
+			continue
+		}
+
+		ruleset := pal.RulesetOf(report.colorReport.Rule)
+		violations[ruleset] = append(violations[ruleset], report.colorReport)
+	}
+
+	b.WriteString("## NoColor report\n\n")
+	if count := len(reports) - len(colorErrors); count != 0 {
+		fmt.Fprintf(&b, "Found %d violations.", count)
+	} else {
+		b.WriteString("No violations found.")
+	}
+	if len(colorErrors) != 0 {
+		fmt.Fprintf(&b, " Found %d errors in the color tags.", len(colorErrors))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("| Ruleset | Violations |\n")
+	b.WriteString("| --- | ---: |\n")
+	for _, ruleset := range pal.Rulesets {
+		fmt.Fprintf(&b, "| %s | %d |\n", markdownCell(ruleset.Name), len(violations[ruleset]))
+	}
+
+	if len(colorErrors) != 0 {
+		b.WriteString("\n### Errors in the color tags\n\n")
+		for _, report := range colorErrors {
+			fmt.Fprintf(&b, "- `%s:%d` %s: `%s`\n", pathutil.Relative(report.File), report.Line, report.Message, report.Context)
+		}
+	}
+
+	for _, ruleset := range pal.Rulesets {
+		if len(violations[ruleset]) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n### %s\n", ruleset.Name)
+		for _, report := range violations[ruleset] {
+			writeMarkdownViolation(&b, report)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownViolation(b *strings.Builder, r *ColorReport) {
+	first := r.CallChain[0].Function
+	last := r.CallChain[len(r.CallChain)-1].Function
+
+	fmt.Fprintf(b, "\n**`%s`** — %s\n\n", r.Rule.String(r.Palette), r.Rule.Error)
+
+	b.WriteString("```mermaid\nflowchart LR\n")
+	for i, node := range r.CallChain {
+		fun := node.Function
+		id := "n" + strconv.Itoa(i)
+
+		label := mermaidText(fun.HumanReadableName())
+		if colors := fun.Colors.String(r.Palette, r.Rule.Masks); colors != "" {
+			label += "<br/>" + mermaidText(colors)
+		}
+
+		fmt.Fprintf(b, "  %s[\"%s\"]\n", id, label)
+		if fill := nodeFill(fun, r.Palette, r.Rule.Masks); fill != "#ffffff" {
+			fmt.Fprintf(b, "  style %s fill:%s\n", id, fill)
+		}
+		if i != 0 {
+			fmt.Fprintf(b, "  n%d -->|%s| %s\n", i-1, kindName(r.CallKinds[i-1]), id)
+		}
+	}
+	b.WriteString("```\n\n")
+
+	filename, line := r.Position()
+	fmt.Fprintf(b, "<details>\n<summary>%s → %s at %s:%d</summary>\n\n",
+		markdownHTML(first.HumanReadableName()), markdownHTML(last.HumanReadableName()),
+		markdownHTML(pathutil.Relative(filename)), line)
+	fmt.Fprintf(b, "```\n%s\n```\n\n", r.PlainText())
+	if r.Fingerprint != "" {
+		fmt.Fprintf(b, "Fingerprint: `%s`\n\n", r.Fingerprint)
+	}
+	b.WriteString("</details>\n")
+}
+
+// mermaidText escapes the text of the label of the node of the Mermaid
+// flowchart, the characters that Mermaid takes for HTML or the end of the
+// label are replaced with the entity codes, like '<in-loop>' of context nodes.
+func mermaidText(s string) string {
+	return strings.NewReplacer("&", "#amp;", "<", "#lt;", ">", "#gt;", `"`, "#quot;").Replace(s)
+}
+
+// markdownCell escapes the text of the cell of the Markdown table.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// markdownHTML escapes the text inside the HTML tags of the Markdown.
+func markdownHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
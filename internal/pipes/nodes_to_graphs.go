@@ -6,15 +6,20 @@ import (
 
 // NodesToGraphs splits the graph into connectivity components.
 //
+// The nodes must contain all the nodes reachable through edges and their IDs
+// must be their indexes, as FunctionsToNodes returns. In the graphs, the
+// nodes get the IDs of their graph.
+//
 // Graphs from one node are skipped.
 func NodesToGraphs(nodes callgraph.Nodes) []*callgraph.Graph {
-	visited := make(map[*callgraph.Node]struct{}, len(nodes))
-	graphs := make([]*callgraph.Graph, 0, 10)
+	visited := make([]bool, len(nodes))
+	var graphs []*callgraph.Graph
 	queue := make(callgraph.Nodes, 0, 10)
 	graphFunctions := make(callgraph.Nodes, 0, 10)
 
-	for _, node := range nodes {
-		if _, ok := visited[node]; ok {
+	for i, node := range nodes {
+		// The IDs of the nodes of the found graphs are already changed.
+		if visited[i] {
 			continue
 		}
 
@@ -24,10 +29,10 @@ func NodesToGraphs(nodes callgraph.Nodes) []*callgraph.Graph {
 			node := queue[0]
 			queue = queue[1:]
 
-			if _, ok := visited[node]; ok {
+			if visited[node.ID] {
 				continue
 			}
-			visited[node] = struct{}{}
+			visited[node.ID] = true
 
 			graphFunctions = append(graphFunctions, node)
 
@@ -35,14 +40,10 @@ func NodesToGraphs(nodes callgraph.Nodes) []*callgraph.Graph {
 			queue = append(queue, node.Prev...)
 		}
 
+		// The IDs are changed only after the whole component is visited,
+		// the other components still have the IDs of the passed nodes.
 		if len(graphFunctions) > 1 {
-			graph, revGraph := functionsToRawGraphs(graphFunctions)
-
-			graphs = append(graphs, &callgraph.Graph{
-				Functions: graphFunctions,
-				Graph:     graph,
-				RevGraph:  revGraph,
-			})
+			graphs = append(graphs, callgraph.NewGraph(graphFunctions))
 		}
 
 		graphFunctions = make(callgraph.Nodes, 0, 10)
@@ -50,15 +51,3 @@ func NodesToGraphs(nodes callgraph.Nodes) []*callgraph.Graph {
 
 	return graphs
 }
-
-func functionsToRawGraphs(funcs callgraph.Nodes) (graph, revGraph callgraph.RawGraph) {
-	graph = make(callgraph.RawGraph, len(funcs))
-	revGraph = make(callgraph.RawGraph, len(funcs))
-
-	for _, node := range funcs {
-		graph[node] = node.Next
-		revGraph[node] = node.Prev
-	}
-
-	return graph, revGraph
-}
@@ -7,19 +7,24 @@ import (
 	"github.com/vkcom/nocolor/internal/callgraph"
 )
 
-// Async starts the passed number of workers to process the graphs passed to
-// the channel, each of which is processed in the passed callback function.
-func Async(workers int, input chan *callgraph.Graph, output chan []*ColorReport, cb func(*callgraph.Graph) []*ColorReport) {
+// Async starts the passed workers to process the graphs passed to the
+// channel, each of which is processed in the passed callback function.
+//
+// The callback is run by one of the workers, so the workers
+// that are free can help it, see CheckColorsInGraph.
+func Async(workers *Workers, input chan *callgraph.Graph, output chan []*ColorReport, cb func(*callgraph.Graph) []*ColorReport) {
 	go func() {
 		var wg sync.WaitGroup
 
-		wg.Add(workers)
-		for i := 0; i < workers; i++ {
+		wg.Add(workers.Count())
+		for i := 0; i < workers.Count(); i++ {
 			go func(id int) {
 				var reports []*ColorReport
 
 				for graph := range input {
-					reports = append(reports, cb(graph)...)
+					workers.Run(func() {
+						reports = append(reports, cb(graph)...)
+					})
 				}
 
 				output <- reports
@@ -50,9 +55,17 @@ func ReadReportsSync(output chan []*ColorReport) []*ColorReport {
 		allReports = append(allReports, reports...)
 	}
 
-	sort.Slice(allReports, func(i, j int) bool {
-		return allReports[i].Message < allReports[j].Message
-	})
+	SortReports(allReports)
 
 	return allReports
 }
+
+// SortReports sorts the reports by their messages and fingerprints.
+func SortReports(reports []*ColorReport) {
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Message != reports[j].Message {
+			return reports[i].Message < reports[j].Message
+		}
+		return reports[i].Fingerprint < reports[j].Fingerprint
+	})
+}
@@ -0,0 +1,278 @@
+package pipes
+
+import (
+	"net/url"
+
+	"github.com/vkcom/nocolor/internal/checkers"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pathutil"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// SARIFSchema is the URI of the JSON schema of SARIF 2.1.0.
+const SARIFSchema = "https://docs.oasis-open.org/sarif/sarif/v2.1.0/errata01/os/schemas/sarif-schema-2.1.0.json"
+
+// SARIFFingerprintKey is the key of the fingerprint
+// of the violation in the partial fingerprints.
+const SARIFFingerprintKey = "nocolor/v1"
+
+// SARIFLog is the root object of a SARIF 2.1.0 file.
+//
+// Only the part of the format that is needed to describe
+// the reports is defined, see https://sarifweb.azurewebsites.net.
+type SARIFLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool      `json:"tool"`
+	Results []*SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string       `json:"name"`
+	Version        string       `json:"version,omitempty"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID                   string               `json:"id"`
+	ShortDescription     SARIFMessage         `json:"shortDescription"`
+	FullDescription      SARIFMessage         `json:"fullDescription"`
+	DefaultConfiguration SARIFConfiguration   `json:"defaultConfiguration"`
+	Properties           *SARIFRuleProperties `json:"properties,omitempty"`
+}
+
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFRuleProperties struct {
+	Ruleset string `json:"ruleset"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             SARIFMessage      `json:"message"`
+	Locations           []*SARIFLocation  `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	CodeFlows           []*SARIFCodeFlow  `json:"codeFlows,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	Message          *SARIFMessage          `json:"message,omitempty"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type SARIFCodeFlow struct {
+	ThreadFlows []*SARIFThreadFlow `json:"threadFlows"`
+}
+
+type SARIFThreadFlow struct {
+	Locations []*SARIFThreadFlowLocation `json:"locations"`
+}
+
+type SARIFThreadFlowLocation struct {
+	Location   *SARIFLocation                     `json:"location"`
+	Kinds      []string                           `json:"kinds,omitempty"`
+	Properties *SARIFThreadFlowLocationProperties `json:"properties,omitempty"`
+}
+
+type SARIFThreadFlowLocationProperties struct {
+	CallKind string `json:"callKind"`
+}
+
+// NewSARIFLog converts the reports to a SARIF log with a single run.
+//
+// Each rule of the palette is a SARIF rule described by its ruleset, and
+// each violation is a result, the call chain of which is a thread flow
+// from the first colored function to the last one. Reports of the
+// collecting colors stage are results of the rules of the checkers.
+func NewSARIFLog(reports []*GeneralReport, pal *palette.Palette, version string) *SARIFLog {
+	driver := SARIFDriver{
+		Name:           "nocolor",
+		Version:        version,
+		InformationURI: "https://github.com/VKCOM/nocolor",
+	}
+
+	ruleIndexes := map[*palette.Rule]int{}
+	for _, ruleset := range pal.Rulesets {
+		for _, rule := range ruleset.Rules {
+			ruleIndexes[rule] = len(driver.Rules)
+			driver.Rules = append(driver.Rules, newSARIFRule(rule, ruleset, pal))
+		}
+	}
+
+	checkerIndexes := map[string]int{}
+	for _, info := range checkers.List() {
+		checkerIndexes[info.Name] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, &SARIFRule{
+			ID:                   info.Name,
+			ShortDescription:     SARIFMessage{Text: info.Comment},
+			FullDescription:      SARIFMessage{Text: info.Comment},
+			DefaultConfiguration: SARIFConfiguration{Level: "error"},
+		})
+	}
+
+	results := make([]*SARIFResult, 0, len(reports))
+	for _, report := range reports {
+		if report.colorReport != nil {
+			index := ruleIndexes[report.colorReport.Rule]
+			results = append(results, newSARIFColorResult(report.colorReport, driver.Rules[index].ID, index))
+			continue
+		}
+
+		index, ok := checkerIndexes[report.linterReport.CheckName]
+		if !ok {
+			index = len(driver.Rules)
+			checkerIndexes[report.linterReport.CheckName] = index
+			driver.Rules = append(driver.Rules, &SARIFRule{
+				ID:                   report.linterReport.CheckName,
+				ShortDescription:     SARIFMessage{Text: report.linterReport.CheckName},
+				FullDescription:      SARIFMessage{Text: report.linterReport.CheckName},
+				DefaultConfiguration: SARIFConfiguration{Level: "error"},
+			})
+		}
+
+		results = append(results, &SARIFResult{
+			RuleID:    report.linterReport.CheckName,
+			RuleIndex: index,
+			Level:     "error",
+			Message:   SARIFMessage{Text: report.Message},
+			Locations: []*SARIFLocation{
+				{PhysicalLocation: newSARIFPhysicalLocation(report.File, report.Line)},
+			},
+		})
+	}
+
+	return &SARIFLog{
+		Schema:  SARIFSchema,
+		Version: "2.1.0",
+		Runs: []*SARIFRun{
+			{
+				Tool:    SARIFTool{Driver: driver},
+				Results: results,
+			},
+		},
+	}
+}
+
+func newSARIFRule(rule *palette.Rule, ruleset *palette.Ruleset, pal *palette.Palette) *SARIFRule {
+	sarifRule := &SARIFRule{
+		ID:                   rule.String(pal),
+		ShortDescription:     SARIFMessage{Text: rule.Error},
+		FullDescription:      SARIFMessage{Text: ruleset.Name},
+		DefaultConfiguration: SARIFConfiguration{Level: "error"},
+		Properties:           &SARIFRuleProperties{Ruleset: ruleset.Name},
+	}
+
+	// Exceptions never produce results, but they are listed
+	// along with the errors, since they are a part of the ruleset.
+	if !rule.IsError() {
+		sarifRule.ShortDescription.Text = "Allowed chain of colors"
+		sarifRule.DefaultConfiguration.Level = "none"
+	}
+
+	return sarifRule
+}
+
+func newSARIFColorResult(r *ColorReport, ruleID string, ruleIndex int) *SARIFResult {
+	flow := &SARIFThreadFlow{}
+	for i, node := range r.CallChain {
+		text := r.nodeName(node)
+
+		// Each hop points to the call of the next function,
+		// and the last one points to the function itself.
+		location := &SARIFThreadFlowLocation{
+			Location: &SARIFLocation{Message: &SARIFMessage{Text: text}},
+		}
+		if i < len(r.CallSites) {
+			location.Kinds = []string{"call"}
+			location.Properties = &SARIFThreadFlowLocationProperties{CallKind: kindName(r.CallKinds[i])}
+			location.Location.PhysicalLocation = newSARIFCallSiteLocation(r.CallSites[i], node.Function)
+		} else {
+			location.Location.PhysicalLocation = newSARIFPhysicalLocation(node.Function.Pos.Filename, int(node.Function.Pos.Line))
+		}
+
+		flow.Locations = append(flow.Locations, location)
+	}
+
+	// The chains that start at the file scope are located
+	// by the file and the first call, see Position.
+	filename, line := r.Position()
+
+	return &SARIFResult{
+		RuleID:    ruleID,
+		RuleIndex: ruleIndex,
+		Level:     "error",
+		Message:   SARIFMessage{Text: r.Rule.Error},
+		Locations: []*SARIFLocation{
+			{PhysicalLocation: newSARIFPhysicalLocation(filename, line)},
+		},
+		PartialFingerprints: map[string]string{
+			SARIFFingerprintKey: r.Fingerprint,
+		},
+		CodeFlows: []*SARIFCodeFlow{
+			{ThreadFlows: []*SARIFThreadFlow{flow}},
+		},
+	}
+}
+
+// newSARIFCallSiteLocation returns the location of the call site, or the
+// location of the calling function, if the call is not in the code.
+func newSARIFCallSiteLocation(site symbols.CallSite, caller *symbols.Function) *SARIFPhysicalLocation {
+	if site.Empty() {
+		return newSARIFPhysicalLocation(caller.Pos.Filename, int(caller.Pos.Line))
+	}
+	return newSARIFPhysicalLocation(site.Filename, site.Line)
+}
+
+// newSARIFPhysicalLocation returns the location of the line of the file,
+// or nil for the functions that are not in any file, like the context nodes.
+func newSARIFPhysicalLocation(filename string, line int) *SARIFPhysicalLocation {
+	if filename == "" {
+		return nil
+	}
+
+	location := &SARIFPhysicalLocation{
+		ArtifactLocation: SARIFArtifactLocation{URI: sarifURI(filename)},
+	}
+	if line > 0 {
+		location.Region = &SARIFRegion{StartLine: line}
+	}
+
+	return location
+}
+
+// sarifURI returns the URI of the file relative to the working
+// directory, so the results are independent of the checkout path.
+func sarifURI(filename string) string {
+	uri := url.URL{Path: pathutil.Relative(filename)}
+	return uri.String()
+}
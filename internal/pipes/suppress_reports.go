@@ -0,0 +1,83 @@
+package pipes
+
+import (
+	"sort"
+
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// SuppressReports removes the reports of the violations whose chain passes
+// through a function with the '@nocolor-ignore' tag for the rule of the
+// violation or its ruleset, such tags are marked as used.
+func SuppressReports(reports []*ColorReport) []*ColorReport {
+	left := make([]*ColorReport, 0, len(reports))
+	for _, report := range reports {
+		if !suppressReport(report) {
+			left = append(left, report)
+		}
+	}
+
+	return left
+}
+
+func suppressReport(r *ColorReport) bool {
+	rule := r.Rule.String(r.Palette)
+
+	var ruleset string
+	if rs := r.Palette.RulesetOf(r.Rule); rs != nil {
+		ruleset = rs.Name
+	}
+
+	// All matching tags are marked, so that the redundant
+	// ones are not reported as unused.
+	suppressed := false
+	for _, node := range r.CallChain {
+		for _, suppression := range node.Function.Suppressions {
+			if suppression.Target == rule || suppression.Target == ruleset {
+				suppression.Used = true
+				suppressed = true
+			}
+		}
+	}
+
+	return suppressed
+}
+
+// UnusedSuppressions returns the '@nocolor-ignore' tags of the functions
+// and the classes that suppress nothing, sorted by their positions.
+func UnusedSuppressions(funcs *symbols.Functions, classes *symbols.Classes) []*symbols.Suppression {
+	var unused []*symbols.Suppression
+
+	// The tags of a class are shared by its methods.
+	seen := map[*symbols.Suppression]bool{}
+	add := func(suppressions []*symbols.Suppression) {
+		for _, suppression := range suppressions {
+			if suppression.Used || seen[suppression] {
+				continue
+			}
+
+			seen[suppression] = true
+			unused = append(unused, suppression)
+		}
+	}
+
+	for _, fun := range funcs.Functions {
+		add(fun.Suppressions)
+	}
+	// The class without methods has no functions with its tags.
+	for _, class := range classes.Classes {
+		add(class.Suppressions)
+	}
+
+	sort.Slice(unused, func(i, j int) bool {
+		if unused[i].Pos.Filename != unused[j].Pos.Filename {
+			return unused[i].Pos.Filename < unused[j].Pos.Filename
+		}
+		if unused[i].Pos.Line != unused[j].Pos.Line {
+			return unused[i].Pos.Line < unused[j].Pos.Line
+		}
+		return unused[i].Target < unused[j].Target
+	})
+
+	return unused
+}
@@ -0,0 +1,49 @@
+package pipes
+
+// Workers is the number of goroutines shared by the graphs that are checked
+// in parallel, see Async, and by the roots of each large graph, see
+// CheckColorsInGraph, so together they never use more goroutines than passed.
+type Workers struct {
+	free chan struct{}
+}
+
+// NewWorkers creates the passed number of workers, at least one.
+func NewWorkers(count int) *Workers {
+	if count < 1 {
+		count = 1
+	}
+
+	w := &Workers{free: make(chan struct{}, count)}
+	for i := 0; i < count; i++ {
+		w.free <- struct{}{}
+	}
+
+	return w
+}
+
+// Count returns the number of the workers.
+func (w *Workers) Count() int {
+	return cap(w.free)
+}
+
+// Run runs the function by one of the workers, waiting until one is free.
+func (w *Workers) Run(f func()) {
+	<-w.free
+	defer w.release()
+
+	f()
+}
+
+// tryAcquire takes one of the workers if it is free, without waiting.
+func (w *Workers) tryAcquire() bool {
+	select {
+	case <-w.free:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *Workers) release() {
+	w.free <- struct{}{}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/VKCOM/noverify/src/meta"
 
+	"github.com/vkcom/nocolor/internal/namegen"
 	"github.com/vkcom/nocolor/internal/palette"
 )
 
@@ -24,11 +25,23 @@ type Class struct {
 	Pos    meta.ElementPosition
 	Colors *palette.ColorContainer
 
+	// Suppressions are the '@nocolor-ignore' tags of the class,
+	// they are shared by its methods, see Suppression.
+	Suppressions []*Suppression
+
 	WithExplicitConstructor bool
+
+	// Extends is the name of the parent class.
+	// It is filled in only for anonymous classes.
+	Extends string
 }
 
 // HumanReadableName returns a string with a name that is understandable.
 func (f *Class) HumanReadableName() string {
+	if namegen.IsAnonClass(f.Name) {
+		return anonClassReadableName(f.Name)
+	}
+
 	return strings.TrimPrefix(f.Name, `\`)
 }
 
@@ -0,0 +1,93 @@
+package symbols
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/pathutil"
+)
+
+// Edge is a structure for storing a call of the function
+// along with the kinds of this call.
+type Edge struct {
+	Function *Function
+	Kind     edgekind.Kind
+
+	// CallSite is the first position of the call,
+	// empty if the call is not in the code.
+	CallSite CallSite
+}
+
+// CallSite is the position of the expression that calls the function.
+type CallSite struct {
+	Filename string
+	Line     int
+}
+
+// Empty checks if the position is unknown.
+func (s CallSite) Empty() bool {
+	return s.Filename == ""
+}
+
+// String returns the position in the 'file:line' format,
+// where the file is relative to the working directory.
+func (s CallSite) String() string {
+	if s.Empty() {
+		return "unknown"
+	}
+	return pathutil.Relative(s.Filename) + ":" + strconv.Itoa(s.Line)
+}
+
+func (s CallSite) before(other CallSite) bool {
+	if s.Filename != other.Filename {
+		return s.Filename < other.Filename
+	}
+	return s.Line < other.Line
+}
+
+// Edges is a set of calls of functions by their names.
+type Edges struct {
+	mtx   sync.Mutex
+	Edges map[string]*Edge
+}
+
+func NewEdges() *Edges {
+	return &Edges{Edges: map[string]*Edge{}}
+}
+
+func (e *Edges) Get(name string) (*Edge, bool) {
+	edge, ok := e.Edges[name]
+	return edge, ok
+}
+
+func (e *Edges) Raw() map[string]*Edge {
+	return e.Edges
+}
+
+func (e *Edges) Len() int {
+	return len(e.Edges)
+}
+
+// Add adds a call of the function with the given kind. If the call
+// already exists, the kind is added to the kinds of the call.
+func (e *Edges) Add(fun *Function, kind edgekind.Kind) {
+	e.AddCall(fun, kind, CallSite{})
+}
+
+// AddCall adds a call of the function with the given kind at the given
+// position, see Add. Of several positions of the call, the first one is
+// kept, so it does not depend on the order in which the files are walked.
+func (e *Edges) AddCall(fun *Function, kind edgekind.Kind, site CallSite) {
+	e.mtx.Lock()
+	edge, ok := e.Edges[fun.Name]
+	if ok {
+		edge.Kind |= kind
+		if !site.Empty() && (edge.CallSite.Empty() || site.before(edge.CallSite)) {
+			edge.CallSite = site
+		}
+	} else {
+		e.Edges[fun.Name] = &Edge{Function: fun, Kind: kind, CallSite: site}
+	}
+	e.mtx.Unlock()
+}
@@ -2,14 +2,14 @@ package symbols
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 
 	"github.com/VKCOM/noverify/src/meta"
 
+	"github.com/vkcom/nocolor/internal/namegen"
 	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pathutil"
 )
 
 type FunctionType int64
@@ -18,6 +18,18 @@ const (
 	MainFunc FunctionType = iota
 	LocalFunc
 	ExternFunc
+
+	// ClassNode is a node that represents the class itself,
+	// it is used only in the dependencies mode.
+	ClassNode
+
+	// PseudoNode is a node that represents a superglobal
+	// or a language construct, like 'echo' or 'eval'.
+	PseudoNode
+
+	// ContextNode is a node between the caller and the callee
+	// for calls inside loops or try blocks.
+	ContextNode
 )
 
 // Function is a structure for storing information about a function.
@@ -27,8 +39,16 @@ type Function struct {
 	Pos    meta.ElementPosition
 	Colors *palette.ColorContainer
 
-	Called   *Functions
-	CalledBy *Functions
+	// Attributes are the fully qualified names of
+	// the PHP 8 attributes of the function.
+	Attributes []string
+
+	// Suppressions are the '@nocolor-ignore' tags of the function
+	// and, for methods, of their class.
+	Suppressions []*Suppression
+
+	Called   *Edges
+	CalledBy *Edges
 }
 
 // HumanReadableName returns a string with a name that is understandable.
@@ -37,22 +57,62 @@ func (f *Function) HumanReadableName() string {
 		name := f.Name
 		path := name[strings.LastIndex(name, "$")+1:]
 
-		wd, err := os.Getwd()
-		if err == nil {
-			relPath, err := filepath.Rel(wd, path)
-			if err == nil {
-				path = relPath
-			}
-		}
+		return fmt.Sprintf("file '%s' scope", pathutil.Relative(path))
+	}
 
-		path = filepath.ToSlash(path)
+	if f.Type == PseudoNode {
+		return f.Name
+	}
+
+	if f.Type == ContextNode {
+		return f.Name[:strings.Index(f.Name, ">")+1]
+	}
 
-		return fmt.Sprintf("file '%s' scope", path)
+	if f.Type == ClassNode {
+		return strings.TrimPrefix(namegen.ClassFromClassNode(f.Name), `\`) + "::class"
+	}
+
+	if namegen.IsAnonClass(f.Name) {
+		sep := strings.LastIndex(f.Name, "::")
+		return anonClassReadableName(f.Name[:sep]) + f.Name[sep:]
 	}
 
 	return strings.TrimPrefix(f.Name, `\`)
 }
 
+// StableName returns the name of the function that does not depend on the
+// working directory or the lines of the code, the paths in it are relative
+// to the passed root of the project.
+//
+// Therefore, anonymous classes are named by their order in the file,
+// and context nodes are named by their callers and callees.
+func (f *Function) StableName(root string) string {
+	if f.Type == MainFunc {
+		return fmt.Sprintf("file '%s' scope", pathutil.RelativeTo(root, namegen.FileFromFileFunction(f.Name)))
+	}
+
+	if f.Type == ContextNode {
+		// The context node has exactly one caller and one callee.
+		var caller, callee string
+		for _, edge := range f.CalledBy.Edges {
+			caller = edge.Function.StableName(root)
+		}
+		for _, edge := range f.Called.Edges {
+			callee = edge.Function.StableName(root)
+		}
+
+		return f.HumanReadableName() + " " + caller + " -> " + callee
+	}
+
+	if namegen.IsAnonClass(f.Name) {
+		sep := strings.LastIndex(f.Name, "::")
+		filename, _, index := namegen.SplitAnonClass(f.Name[:sep])
+		return fmt.Sprintf("class@anonymous#%s (%s)", index, pathutil.RelativeTo(root, filename)) + f.Name[sep:]
+	}
+
+	return f.HumanReadableName()
+}
+
 func (f *Function) String() string {
 	return f.Name
 }
@@ -88,3 +148,10 @@ func (f *Functions) Add(fun *Function) {
 	f.Functions[fun.Name] = fun
 	f.mtx.Unlock()
 }
+
+// anonClassReadableName returns a name like 'class@anonymous (file.php:12)'
+// for the synthetic name of the anonymous class.
+func anonClassReadableName(name string) string {
+	filename, line, _ := namegen.SplitAnonClass(name)
+	return fmt.Sprintf("class@anonymous (%s:%s)", pathutil.Relative(filename), line)
+}
@@ -0,0 +1,25 @@
+package symbols
+
+import (
+	"github.com/VKCOM/noverify/src/meta"
+)
+
+// SuppressionTag is the PHPDoc tag that suppresses the violations
+// of a rule or a ruleset whose chain passes through the function.
+const SuppressionTag = "nocolor-ignore"
+
+// Suppression is a '@nocolor-ignore' tag of a function or a class,
+// the tag of a class applies to all its methods, so they share it.
+type Suppression struct {
+	// Target is the name of the ruleset or the
+	// colors of the rule, like 'highload no-highload'.
+	Target string
+	Reason string
+
+	// Place is the name of the function or the class with the tag.
+	Place string
+	Pos   meta.ElementPosition
+
+	// Used is set when the tag suppresses at least one violation.
+	Used bool
+}
@@ -0,0 +1,276 @@
+package walkers
+
+import (
+	"github.com/VKCOM/noverify/src/ir"
+	"github.com/VKCOM/noverify/src/ir/irutil"
+	"github.com/VKCOM/noverify/src/linter"
+	"github.com/VKCOM/noverify/src/meta"
+	"github.com/VKCOM/noverify/src/phpdoc"
+	"github.com/VKCOM/noverify/src/solver"
+	"github.com/VKCOM/noverify/src/types"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/namegen"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// NoVerify does not walk the bodies of anonymous classes at the root level,
+// and walks them as part of the enclosing function in the block context.
+//
+// Therefore, all anonymous classes are processed manually when the new
+// expression is found, and the walkers skip everything inside them.
+
+// anonMethod describes the method of the anonymous class,
+// the body of which is currently being walked.
+type anonMethod struct {
+	class    string
+	function *symbols.Function
+}
+
+// anonClassChecker is a walker that handles the body
+// of one method of the anonymous class.
+type anonClassChecker struct {
+	root  *RootChecker
+	scope *meta.Scope
+	path  irutil.NodePath
+}
+
+// EnterNode is method to use anonClassChecker in the Walk method of AST nodes.
+func (a *anonClassChecker) EnterNode(n ir.Node) bool {
+	a.path.Push(n)
+
+	nodePath := func() irutil.NodePath {
+		return a.path
+	}
+
+	a.root.withCallContext(nodePath, func() {
+		a.root.handleCallNode(n, a.scope, a.path, a)
+		a.root.handlePseudoNode(n)
+	})
+
+	switch n.(type) {
+	case *ir.AnonClassExpr:
+		// Nested anonymous classes are handled in handleNew.
+		a.path.Pop()
+		return false
+
+	case *ir.IssetExpr, *ir.EmptyExpr, *ir.UnsetStmt:
+		// Same as NoVerify, we do not walk them, since
+		// their arguments are handled as a whole.
+		a.path.Pop()
+		return false
+	}
+
+	return true
+}
+
+// LeaveNode is method to use anonClassChecker in the Walk method of AST nodes.
+func (a *anonClassChecker) LeaveNode(ir.Node) {
+	a.path.Pop()
+}
+
+func anonClassName(filename string, n *ir.AnonClassExpr, indexes map[*ir.AnonClassExpr]int) string {
+	return namegen.AnonClass(filename, n.Position.StartLine, indexes[n])
+}
+
+// anonClassIndexes returns the ordinal numbers of the anonymous classes of the
+// file in the order of their declarations, unlike the positions, they do not
+// change when the code above the class is edited.
+func anonClassIndexes(root *ir.Root) map[*ir.AnonClassExpr]int {
+	indexes := map[*ir.AnonClassExpr]int{}
+	irutil.Inspect(root, func(n ir.Node) bool {
+		if anon, ok := n.(*ir.AnonClassExpr); ok {
+			indexes[anon] = len(indexes) + 1
+		}
+		return true
+	})
+
+	return indexes
+}
+
+// anonClassDoc returns the PHPDoc of the anonymous class, which can be either
+// before the 'class' keyword or before the entire new expression.
+func anonClassDoc(newExpr *ir.NewExpr, class *ir.AnonClassExpr) phpdoc.Comment {
+	if len(class.Doc.Parsed) != 0 {
+		return class.Doc
+	}
+
+	var doc phpdoc.Comment
+	for _, tkn := range newExpr.NewTkn.FreeFloating {
+		if phpdoc.IsPHPDocToken(tkn) {
+			doc = phpdoc.Parse(phpdoc.NewTypeParser(), string(tkn.Value))
+		}
+	}
+
+	return doc
+}
+
+func (r *RootIndexer) handleAnonClass(n *ir.AnonClassExpr) {
+	irutil.Inspect(n, func(n ir.Node) bool {
+		anon, ok := n.(*ir.AnonClassExpr)
+		if ok {
+			r.indexAnonClass(anon)
+		}
+		return true
+	})
+}
+
+func (r *RootIndexer) indexAnonClass(n *ir.AnonClassExpr) {
+	name := anonClassName(r.ctx.Filename(), n, r.anonClasses)
+
+	class := &symbols.Class{
+		Name:   name,
+		Type:   symbols.PlainClass,
+		Pos:    r.getElementPos(n),
+		Colors: &palette.ColorContainer{},
+	}
+
+	if n.Extends != nil {
+		class.Extends, _ = solver.GetClassName(r.state, n.Extends.ClassName)
+	}
+
+	for _, stmt := range n.Stmts {
+		methodNode, ok := stmt.(*ir.ClassMethodStmt)
+		if !ok {
+			continue
+		}
+
+		if methodNode.MethodName.Value == "__construct" {
+			class.WithExplicitConstructor = true
+		}
+
+		r.meta.Functions.Add(&symbols.Function{
+			Name:       namegen.Method(name, methodNode.MethodName.Value),
+			Type:       symbols.LocalFunc,
+			Pos:        r.getElementPos(methodNode),
+			Colors:     &palette.ColorContainer{},
+			Attributes: r.getAttributes(methodNode.AttrGroups),
+			Called:     symbols.NewEdges(),
+			CalledBy:   symbols.NewEdges(),
+		})
+	}
+
+	if !class.WithExplicitConstructor {
+		r.meta.Functions.Add(&symbols.Function{
+			Name:     namegen.DefaultConstructor(name),
+			Type:     symbols.LocalFunc,
+			Pos:      r.getElementPos(n),
+			Colors:   class.Colors,
+			Called:   symbols.NewEdges(),
+			CalledBy: symbols.NewEdges(),
+		})
+	}
+
+	r.meta.Classes.Add(class)
+}
+
+func (r *RootChecker) handleAnonClass(newExpr *ir.NewExpr, n *ir.AnonClassExpr, v ir.Visitor) {
+	// The arguments are evaluated in the context of the current function.
+	for _, arg := range n.Args {
+		arg.Walk(v)
+	}
+
+	name := anonClassName(r.ctx.Filename(), n, r.anonClasses)
+	class, ok := r.globalCtx.Classes.Get(name)
+	if !ok {
+		return
+	}
+
+	colors, errs := r.colorsFromDoc(anonClassDoc(newExpr, n))
+	for _, err := range errs {
+		r.ctx.Report(newExpr, linter.LevelError, "errorColor", err)
+	}
+
+	*class.Colors = colors
+
+	r.handleClassMethods(name, n.Stmts, colors, nil)
+	r.handleAnonClassDependencies(n)
+
+	constructor, ok := r.findAnonClassMethod(class, "__construct")
+	if !ok && !class.WithExplicitConstructor {
+		constructor, ok = r.globalCtx.Functions.Get(namegen.DefaultConstructor(name))
+	}
+	if ok {
+		r.createEdgeWithCurrent(constructor, edgekind.New)
+	}
+
+	destructor, ok := r.findAnonClassMethod(class, "__destruct")
+	if ok {
+		r.createEdgeWithCurrent(destructor, edgekind.Magic)
+	}
+
+	for _, stmt := range n.Stmts {
+		methodNode, ok := stmt.(*ir.ClassMethodStmt)
+		if !ok || methodNode.Stmt == nil {
+			continue
+		}
+
+		method, ok := r.globalCtx.Functions.Get(namegen.Method(name, methodNode.MethodName.Value))
+		if !ok {
+			continue
+		}
+
+		scope := meta.NewScope()
+		scope.AddVarName("this", types.NewMap(name), "$this", meta.VarAlwaysDefined)
+
+		r.anonMethods = append(r.anonMethods, anonMethod{class: name, function: method})
+		methodNode.Stmt.Walk(&anonClassChecker{root: r, scope: scope})
+		r.anonMethods = r.anonMethods[:len(r.anonMethods)-1]
+	}
+}
+
+// findAnonClassMethod finds the method either in the anonymous
+// class itself or in the class that it extends.
+func (r *RootChecker) findAnonClassMethod(class *symbols.Class, methodName string) (*symbols.Function, bool) {
+	method, ok := r.globalCtx.Functions.Get(namegen.Method(class.Name, methodName))
+	if ok {
+		return method, true
+	}
+
+	if class.Extends == "" {
+		return nil, false
+	}
+
+	methodInfo, ok := solver.FindMethod(r.state.Info, class.Extends, methodName)
+	if !ok {
+		return nil, false
+	}
+
+	return r.globalCtx.Functions.Get(namegen.Method(methodInfo.ImplName(), methodName))
+}
+
+// handleAnonClassMethodCall creates an edge with the method of the anonymous class
+// or, if there is no such method, with the '__call' or '__callStatic' magic method.
+func (r *RootChecker) handleAnonClassMethodCall(className, methodName string, kind edgekind.Kind) {
+	class, ok := r.globalCtx.Classes.Get(className)
+	if !ok {
+		return
+	}
+
+	method, ok := r.findAnonClassMethod(class, methodName)
+	if ok {
+		r.createEdgeWithCurrent(method, kind)
+		return
+	}
+
+	magicMethodName := "__call"
+	if kind == edgekind.Static {
+		magicMethodName = "__callStatic"
+	}
+
+	method, ok = r.findAnonClassMethod(class, magicMethodName)
+	if ok {
+		r.createEdgeWithCurrent(method, edgekind.Magic)
+	}
+}
+
+// currentAnonClass returns the name of the anonymous class,
+// the method of which is currently being walked.
+func (r *RootChecker) currentAnonClass() (string, bool) {
+	if len(r.anonMethods) == 0 {
+		return "", false
+	}
+
+	return r.anonMethods[len(r.anonMethods)-1].class, true
+}
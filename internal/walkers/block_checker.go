@@ -2,12 +2,46 @@ package walkers
 
 import (
 	"github.com/VKCOM/noverify/src/ir"
+	"github.com/VKCOM/noverify/src/ir/irutil"
 	"github.com/VKCOM/noverify/src/linter"
 )
 
-// BlockIndexer is a dummy walker.
+// BlockIndexer is a walker that collects information about
+// anonymous classes in block context (inside functions).
 type BlockIndexer struct {
 	linter.BlockCheckerDefaults
+	root *RootIndexer
+
+	// anonClassDepth is the nesting level of anonymous classes,
+	// their bodies are handled separately, so they must be skipped.
+	anonClassDepth int
+}
+
+// NewBlockIndexer creates a new BlockIndexer walker.
+func NewBlockIndexer(root *RootIndexer) *BlockIndexer {
+	return &BlockIndexer{
+		root: root,
+	}
+}
+
+// BeforeEnterNode collects information about anonymous classes.
+func (b *BlockIndexer) BeforeEnterNode(n ir.Node) {
+	anon, ok := n.(*ir.AnonClassExpr)
+	if !ok {
+		return
+	}
+
+	if b.anonClassDepth == 0 {
+		b.root.handleAnonClass(anon)
+	}
+	b.anonClassDepth++
+}
+
+// BeforeLeaveNode tracks the exit from anonymous classes.
+func (b *BlockIndexer) BeforeLeaveNode(n ir.Node) {
+	if _, ok := n.(*ir.AnonClassExpr); ok {
+		b.anonClassDepth--
+	}
 }
 
 // BlockChecker is a walker that handles function calls, method calls,
@@ -16,45 +50,62 @@ type BlockChecker struct {
 	linter.BlockCheckerDefaults
 	ctx  *linter.BlockContext
 	root *RootChecker
+
+	// walkPath is the path of the nodes that are walked explicitly
+	// by the checker, NoVerify does not add them to its path.
+	walkPath irutil.NodePath
 }
 
 // NewBlockChecker creates a new BlockChecker walker.
 func NewBlockChecker(ctx *linter.BlockContext, root *RootChecker) *BlockChecker {
 	return &BlockChecker{
-		ctx:  ctx,
-		root: root,
+		ctx:      ctx,
+		root:     root,
+		walkPath: irutil.NewNodePath(),
 	}
 }
 
 // EnterNode is method to use BlockChecker in the Walk method of AST nodes.
 func (b *BlockChecker) EnterNode(n ir.Node) bool {
+	b.walkPath.Push(n)
 	b.AfterEnterNode(n)
+
+	switch n.(type) {
+	case *ir.IssetExpr, *ir.EmptyExpr, *ir.UnsetStmt:
+		// Same as NoVerify, we do not walk them, since
+		// their arguments are handled as a whole.
+		b.walkPath.Pop()
+		return false
+	}
+
 	return true
 }
 
 // LeaveNode is method to use BlockChecker in the Walk method of AST nodes.
-func (b *BlockChecker) LeaveNode(n ir.Node) {}
+func (b *BlockChecker) LeaveNode(ir.Node) {
+	b.walkPath.Pop()
+}
 
 // AfterEnterNode is the main method for processing AST nodes.
 func (b *BlockChecker) AfterEnterNode(n ir.Node) {
-	switch n := n.(type) {
-	case *ir.NewExpr:
-		b.root.handleNew(n, b.ctx.Scope())
-	case *ir.FunctionCallExpr:
-		b.root.handleFunctionCall(n, b.ctx.Scope(), b)
-	case *ir.StaticCallExpr:
-		b.root.handleStaticCall(n, b.ctx.Scope())
-	case *ir.MethodCallExpr:
-		b.root.handleMethodCall(n, b.ctx.Scope(), b)
-	case *ir.NullsafeMethodCallExpr:
-		b.root.handleNullsafeMethodCall(n, b.ctx.Scope(), b)
-	case *ir.ImportExpr:
-		b.root.handleImportExpr(n)
-	case *ir.CloneExpr:
-		b.root.handleCloneExpr(n, b.ctx.Scope())
-	case *ir.PropertyFetchExpr:
-		b.root.handlePropertyFetch(n, b.ctx.Scope(), b.ctx.NodePath())
+	// The bodies of anonymous classes are handled in handleNew.
+	//
+	// The nesting is checked by the path, since at the root level NoVerify
+	// does not walk the anonymous classes, and so does not leave them.
+	if inAnonClass(b.ctx.NodePath()) || inAnonClass(b.walkPath) {
+		return
+	}
 
+	nodePath := func() irutil.NodePath {
+		return joinNodePaths(b.ctx.NodePath(), b.walkPath)
+	}
+
+	b.root.withCallContext(nodePath, func() {
+		b.root.handleCallNode(n, b.ctx.Scope(), b.ctx.NodePath(), b)
+		b.root.handlePseudoNode(n)
+	})
+
+	switch n := n.(type) {
 	case *ir.Assign:
 		// Because of the way we handle assignments,
 		// we have to redirect our walker explicitly.
@@ -62,3 +113,13 @@ func (b *BlockChecker) AfterEnterNode(n ir.Node) {
 		n.Expr.Walk(b)
 	}
 }
+
+// inAnonClass checks if the path contains an anonymous class.
+func inAnonClass(path irutil.NodePath) bool {
+	for i := 0; path.NthParent(i) != nil; i++ {
+		if _, ok := path.NthParent(i).(*ir.AnonClassExpr); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package walkers
+
+import (
+	"strings"
+
+	"github.com/VKCOM/noverify/src/ir"
+	"github.com/VKCOM/noverify/src/ir/irutil"
+
+	"github.com/vkcom/nocolor/internal/namegen"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// Calls inside loops and try blocks are modeled with a context node placed
+// between the caller and the callee. This node is colored with the implicit
+// 'in-loop' and 'in-try' colors, so rules like "fast in-loop db" can be
+// written. Context nodes are created only if these colors are used in the
+// palette and are separate for each pair of the caller and the callee.
+
+const (
+	inLoopColor = "in-loop"
+	inTryColor  = "in-try"
+)
+
+// callSiteContext returns the implicit colors of the current node
+// in the order of nesting, from the outermost to the innermost.
+func callSiteContext(nodePath irutil.NodePath) []string {
+	var colors []string
+
+	for i := 1; nodePath.NthParent(i) != nil; i++ {
+		child := nodePath.NthParent(i - 1)
+
+		var color string
+		switch parent := nodePath.NthParent(i).(type) {
+		case *ir.ForStmt:
+			if child == parent.Stmt || containsNode(parent.Cond, child) || containsNode(parent.Loop, child) {
+				color = inLoopColor
+			}
+		case *ir.ForeachStmt:
+			if child == parent.Stmt {
+				color = inLoopColor
+			}
+		case *ir.WhileStmt:
+			if child == parent.Stmt || child == parent.Cond {
+				color = inLoopColor
+			}
+		case *ir.DoStmt:
+			if child == parent.Stmt || child == parent.Cond {
+				color = inLoopColor
+			}
+		case *ir.TryStmt:
+			if containsNode(parent.Stmts, child) {
+				color = inTryColor
+			}
+		case *ir.ClosureExpr, *ir.ArrowFunctionExpr, *ir.FunctionStmt, *ir.ClassMethodStmt:
+			// The body of the function is not executed at the place of declaration.
+			return colors
+		}
+
+		if color == "" || (len(colors) != 0 && colors[0] == color) {
+			continue
+		}
+
+		colors = append([]string{color}, colors...)
+	}
+
+	return colors
+}
+
+// joinNodePaths returns the path in which the
+// second path continues the first one.
+func joinNodePaths(first, second irutil.NodePath) irutil.NodePath {
+	if second.Current() == nil {
+		return first
+	}
+
+	path := irutil.NewNodePath()
+	for _, nodePath := range []irutil.NodePath{first, second} {
+		depth := 0
+		for nodePath.NthParent(depth) != nil {
+			depth++
+		}
+
+		for i := depth - 1; i >= 0; i-- {
+			path.Push(nodePath.NthParent(i))
+		}
+	}
+
+	return path
+}
+
+func containsNode(nodes []ir.Node, n ir.Node) bool {
+	for _, node := range nodes {
+		if node == n {
+			return true
+		}
+	}
+	return false
+}
+
+// callContextNode returns the context node for the call of the
+// callee from the caller in the current context, if the context
+// colors are used in the palette.
+func (r *RootChecker) callContextNode(caller, callee *symbols.Function) (*symbols.Function, bool) {
+	var colors palette.ColorContainer
+	var names []string
+
+	for _, name := range r.callContext {
+		if !r.palette.ColorExists(name) {
+			continue
+		}
+
+		colors.Add(r.palette.GetColorByName(name))
+		names = append(names, name)
+	}
+
+	if colors.Empty() {
+		return nil, false
+	}
+
+	name := namegen.CallContext(strings.Join(names, " "), caller.Name, callee.Name)
+	if node, ok := r.contextNodes[name]; ok {
+		return node, true
+	}
+
+	node := &symbols.Function{
+		Name:     name,
+		Type:     symbols.ContextNode,
+		Pos:      caller.Pos,
+		Colors:   &colors,
+		Called:   symbols.NewEdges(),
+		CalledBy: symbols.NewEdges(),
+	}
+	r.contextNodes[name] = node
+
+	return node, true
+}
+
+// usesCallContext checks if the context colors are used in the palette,
+// if not, the context of the calls is not needed.
+func usesCallContext(pal *palette.Palette) bool {
+	return pal.ColorExists(inLoopColor) || pal.ColorExists(inTryColor)
+}
+
+// withCallContext calls the callback with the call context of the node
+// path, which is only built if the context colors are used in the palette.
+func (r *RootChecker) withCallContext(nodePath func() irutil.NodePath, cb func()) {
+	if !r.usesCallContext {
+		cb()
+		return
+	}
+
+	prevContext := r.callContext
+	r.callContext = callSiteContext(nodePath())
+	cb()
+	r.callContext = prevContext
+}
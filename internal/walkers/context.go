@@ -4,10 +4,12 @@ import (
 	"encoding/gob"
 	"io"
 	"log"
+	"sync"
 
 	"github.com/VKCOM/noverify/src/linter"
 	"github.com/VKCOM/noverify/src/meta"
 
+	"github.com/vkcom/nocolor/internal/includes"
 	"github.com/vkcom/nocolor/internal/symbols"
 )
 
@@ -17,20 +19,42 @@ type GlobalContext struct {
 
 	Functions *symbols.Functions
 	Classes   *symbols.Classes
+
+	// Dependencies enables the dependencies mode, in which the
+	// references to classes by type are also added to the graph.
+	Dependencies bool
+
+	// Includes describes how included files are resolved.
+	Includes *includes.Config
+	// UnresolvedIncludes are the includes that could not be resolved.
+	UnresolvedIncludes *includes.UnresolvedList
+
+	pseudoNodes sync.Once
 }
 
 // NewGlobalContext creates a new context.
 func NewGlobalContext(info *meta.Info) *GlobalContext {
-	return &GlobalContext{
+	ctx := &GlobalContext{
 		Info:      info,
 		Functions: symbols.NewFunctions(),
 		Classes:   symbols.NewClasses(),
+
+		Includes:           includes.NewConfig(),
+		UnresolvedIncludes: &includes.UnresolvedList{},
 	}
+
+	return ctx
 }
 
 // Version returns the current version of the cache.
+//
+// The class nodes are indexed only in the dependencies
+// mode, so it has its own version of the cache.
 func (ctx *GlobalContext) Version() string {
-	return "1.0.0"
+	if ctx.Dependencies {
+		return "1.0.4-dependencies"
+	}
+	return "1.0.4"
 }
 
 // Encode caches the data of one rootWalker of one file.
@@ -0,0 +1,210 @@
+package walkers
+
+import (
+	"github.com/VKCOM/noverify/src/ir"
+	"github.com/VKCOM/noverify/src/ir/irutil"
+	"github.com/VKCOM/noverify/src/solver"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/namegen"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// In the dependencies mode, each class is represented by a separate node
+// that has the colors of the class. Classes and functions get edges to the
+// nodes of the classes they reference by type: in extends, implements, trait
+// uses, type hints, catch, instanceof and '::class'.
+//
+// Since these nodes are ordinary nodes of the graph, the same palette
+// is checked both for calls and for dependencies.
+
+func (r *RootIndexer) indexClassNode(className string, n ir.Node) {
+	if !r.globalCtx.Dependencies {
+		return
+	}
+
+	r.meta.Functions.Add(&symbols.Function{
+		Name:     namegen.ClassNode(className),
+		Type:     symbols.ClassNode,
+		Pos:      r.getElementPos(n),
+		Colors:   &palette.ColorContainer{},
+		Called:   symbols.NewEdges(),
+		CalledBy: symbols.NewEdges(),
+	})
+}
+
+// handleClassNode sets the colors and the '@nocolor-ignore' tags of the class
+// node and creates edges with the classes referenced in the class declaration.
+func (r *RootChecker) handleClassNode(className string, colors palette.ColorContainer, suppressions []*symbols.Suppression, refs []ir.Node) {
+	if !r.globalCtx.Dependencies {
+		return
+	}
+
+	classNode, ok := r.globalCtx.Functions.Get(namegen.ClassNode(className))
+	if !ok {
+		return
+	}
+
+	*classNode.Colors = colors
+	classNode.Suppressions = suppressions
+
+	for _, name := range r.referencedClasses(refs...) {
+		r.createTypeRefEdge(classNode, name)
+	}
+}
+
+// handleSignatureDependencies creates edges with the classes
+// referenced in the type hints of the function parameters
+// and the return type.
+func (r *RootChecker) handleSignatureDependencies(fun *symbols.Function, params []ir.Node, returnType ir.Node) {
+	if !r.globalCtx.Dependencies {
+		return
+	}
+
+	for _, name := range r.referencedClasses(signatureTypes(params, returnType)...) {
+		r.createTypeRefEdge(fun, name)
+	}
+}
+
+// handleTypeRefNode creates edges with the classes referenced
+// inside the body of the current function.
+func (r *RootChecker) handleTypeRefNode(n ir.Node) {
+	if !r.globalCtx.Dependencies {
+		return
+	}
+
+	var refs []ir.Node
+
+	switch n := n.(type) {
+	case *ir.CatchStmt:
+		refs = n.Types
+	case *ir.InstanceOfExpr:
+		refs = []ir.Node{n.Class}
+	case *ir.ClassConstFetchExpr:
+		if n.ConstantName.Value == "class" {
+			refs = []ir.Node{n.Class}
+		}
+	case *ir.ClosureExpr:
+		refs = signatureTypes(n.Params, n.ReturnType)
+	case *ir.ArrowFunctionExpr:
+		refs = signatureTypes(n.Params, n.ReturnType)
+	}
+
+	if len(refs) == 0 {
+		return
+	}
+
+	curFunc, ok := r.getCurrentFunc()
+	if !ok {
+		return
+	}
+
+	for _, name := range r.referencedClasses(refs...) {
+		r.createTypeRefEdge(curFunc, name)
+	}
+}
+
+// handleAnonClassDependencies creates edges from the current function with
+// the classes referenced in the declaration of the anonymous class, since
+// the anonymous class does not have its own class node.
+func (r *RootChecker) handleAnonClassDependencies(n *ir.AnonClassExpr) {
+	if !r.globalCtx.Dependencies {
+		return
+	}
+
+	curFunc, ok := r.getCurrentFunc()
+	if !ok {
+		return
+	}
+
+	for _, name := range r.referencedClasses(classDeclarationTypes(&n.Class)...) {
+		r.createTypeRefEdge(curFunc, name)
+	}
+}
+
+func (r *RootChecker) createTypeRefEdge(from *symbols.Function, className string) {
+	classNode, ok := r.globalCtx.Functions.Get(namegen.ClassNode(className))
+	if !ok || classNode == from {
+		return
+	}
+
+	site := r.callSite()
+	from.Called.AddCall(classNode, edgekind.TypeRef, site)
+	classNode.CalledBy.AddCall(from, edgekind.TypeRef, site)
+}
+
+// referencedClasses returns the fully qualified names
+// of all classes mentioned in the passed nodes.
+func (r *RootChecker) referencedClasses(nodes ...ir.Node) []string {
+	var names []string
+
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+
+		irutil.Inspect(n, func(n ir.Node) bool {
+			name, ok := n.(*ir.Name)
+			if !ok {
+				return true
+			}
+
+			className, ok := solver.GetClassName(r.state, name)
+			if ok {
+				names = append(names, className)
+			}
+			return false
+		})
+	}
+
+	return names
+}
+
+// classDeclarationTypes returns the nodes of the class declaration that
+// reference other classes: extends, implements, trait uses and types of
+// properties.
+func classDeclarationTypes(class *ir.Class) []ir.Node {
+	var refs []ir.Node
+
+	if class.Extends != nil {
+		refs = append(refs, class.Extends.ClassName)
+	}
+	if class.Implements != nil {
+		refs = append(refs, class.Implements.InterfaceNames...)
+	}
+
+	return append(refs, classBodyTypes(class.Stmts)...)
+}
+
+// classBodyTypes returns the trait uses and types of properties.
+func classBodyTypes(stmts []ir.Node) []ir.Node {
+	var refs []ir.Node
+
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ir.TraitUseStmt:
+			refs = append(refs, stmt.Traits...)
+		case *ir.PropertyListStmt:
+			refs = append(refs, stmt.Type)
+		}
+	}
+
+	return refs
+}
+
+// signatureTypes returns the type hints of the parameters and the return type.
+func signatureTypes(params []ir.Node, returnType ir.Node) []ir.Node {
+	refs := make([]ir.Node, 0, len(params)+1)
+
+	for _, param := range params {
+		p, ok := param.(*ir.Parameter)
+		if !ok {
+			continue
+		}
+
+		refs = append(refs, p.VariableType)
+	}
+
+	return append(refs, returnType)
+}
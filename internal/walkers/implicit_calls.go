@@ -0,0 +1,157 @@
+package walkers
+
+import (
+	"github.com/VKCOM/noverify/src/ir"
+	"github.com/VKCOM/noverify/src/ir/irutil"
+	"github.com/VKCOM/noverify/src/meta"
+	"github.com/VKCOM/noverify/src/solver"
+	"github.com/VKCOM/noverify/src/types"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/namegen"
+)
+
+// handleImplicitMethodCall creates edges with the methods that PHP calls
+// implicitly for the value of the passed expression.
+//
+// If iface is not empty, then only the classes that implement
+// it are taken into account.
+func (r *RootChecker) handleImplicitMethodCall(expr ir.Node, blockScope *meta.Scope, kind edgekind.Kind, iface string, methodNames ...string) {
+	scope := blockScope
+	if scope == nil {
+		scope = r.ctx.Scope()
+	}
+
+	classTypes := solver.ExprType(scope, r.state, expr)
+	r.handleImplicitMethods(classTypes, kind, iface, methodNames...)
+}
+
+// handleImplicitMethods creates edges with the passed methods of
+// the classes, if the classes define them.
+func (r *RootChecker) handleImplicitMethods(classTypes types.Map, kind edgekind.Kind, iface string, methodNames ...string) {
+	classTypes.Iterate(func(classType string) {
+		if !types.IsClass(classType) {
+			return
+		}
+
+		if namegen.IsAnonClass(classType) {
+			r.handleAnonClassImplicitMethods(classType, kind, methodNames)
+			return
+		}
+
+		if iface != "" && !solver.Implements(r.state.Info, classType, iface) {
+			return
+		}
+
+		for _, methodName := range methodNames {
+			methodInfo, ok := solver.FindMethod(r.state.Info, classType, methodName)
+			if !ok || methodInfo.Info.IsFromAnnotation() {
+				continue
+			}
+
+			calledFunc, ok := r.globalCtx.Functions.Get(namegen.Method(methodInfo.ImplName(), methodName))
+			if !ok {
+				continue
+			}
+
+			r.createEdgeWithCurrent(calledFunc, kind)
+		}
+	})
+}
+
+func (r *RootChecker) handleAnonClassImplicitMethods(className string, kind edgekind.Kind, methodNames []string) {
+	class, ok := r.globalCtx.Classes.Get(className)
+	if !ok {
+		return
+	}
+
+	for _, methodName := range methodNames {
+		method, ok := r.findAnonClassMethod(class, methodName)
+		if !ok {
+			continue
+		}
+
+		r.createEdgeWithCurrent(method, kind)
+	}
+}
+
+// handleToString handles the expressions that are converted to string.
+func (r *RootChecker) handleToString(expr ir.Node, blockScope *meta.Scope) {
+	if _, ok := expr.(*ir.EncapsedStringPart); ok {
+		return
+	}
+
+	r.handleImplicitMethodCall(expr, blockScope, edgekind.Magic, "", "__toString")
+}
+
+func (r *RootChecker) handleArrayDimFetch(n *ir.ArrayDimFetchExpr, blockScope *meta.Scope, nodePath irutil.NodePath) {
+	if r.inIssetOrUnset(nodePath) {
+		return
+	}
+
+	methodName := "offsetGet"
+	if r.isAssignTarget(nodePath, n) {
+		methodName = "offsetSet"
+	}
+
+	r.handleImplicitMethodCall(n.Variable, blockScope, edgekind.Magic, `\ArrayAccess`, methodName)
+}
+
+func (r *RootChecker) handleIsset(n *ir.IssetExpr, blockScope *meta.Scope) {
+	for _, variable := range n.Variables {
+		switch variable := variable.(type) {
+		case *ir.PropertyFetchExpr:
+			r.handlePropertyMagicMethod(variable, blockScope, "__isset")
+		case *ir.ArrayDimFetchExpr:
+			r.handleImplicitMethodCall(variable.Variable, blockScope, edgekind.Magic, `\ArrayAccess`, "offsetExists")
+		}
+	}
+}
+
+func (r *RootChecker) handleEmpty(n *ir.EmptyExpr, blockScope *meta.Scope) {
+	// If the value exists, then it is also fetched to check it.
+	switch expr := n.Expr.(type) {
+	case *ir.PropertyFetchExpr:
+		r.handlePropertyMagicMethod(expr, blockScope, "__isset")
+		r.handlePropertyMagicMethod(expr, blockScope, "__get")
+	case *ir.ArrayDimFetchExpr:
+		r.handleImplicitMethodCall(expr.Variable, blockScope, edgekind.Magic, `\ArrayAccess`, "offsetExists", "offsetGet")
+	}
+}
+
+func (r *RootChecker) handleUnset(n *ir.UnsetStmt, blockScope *meta.Scope) {
+	for _, variable := range n.Vars {
+		switch variable := variable.(type) {
+		case *ir.PropertyFetchExpr:
+			r.handlePropertyMagicMethod(variable, blockScope, "__unset")
+		case *ir.ArrayDimFetchExpr:
+			r.handleImplicitMethodCall(variable.Variable, blockScope, edgekind.Magic, `\ArrayAccess`, "offsetUnset")
+		}
+	}
+}
+
+func (r *RootChecker) handleForeach(n *ir.ForeachStmt, blockScope *meta.Scope) {
+	r.handleImplicitMethodCall(n.Expr, blockScope, edgekind.Magic, `\IteratorAggregate`, "getIterator")
+	r.handleImplicitMethodCall(n.Expr, blockScope, edgekind.Magic, `\Iterator`, "rewind", "valid", "current", "key", "next")
+}
+
+// isAssignTarget checks if the node is the left side of the closest assignment.
+func (r *RootChecker) isAssignTarget(nodePath irutil.NodePath, n ir.Node) bool {
+	for i := 0; nodePath.NthParent(i) != nil; i++ {
+		assign, ok := nodePath.NthParent(i).(*ir.Assign)
+		if ok {
+			return assign.Variable == n
+		}
+	}
+	return false
+}
+
+// inIssetOrUnset checks if the current node is an argument of isset, empty or unset,
+// for such arguments, other magic methods are called, see handleIsset and others.
+func (r *RootChecker) inIssetOrUnset(nodePath irutil.NodePath) bool {
+	switch nodePath.NthParent(1).(type) {
+	case *ir.IssetExpr, *ir.EmptyExpr, *ir.UnsetStmt:
+		return true
+	}
+	return false
+}
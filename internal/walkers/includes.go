@@ -0,0 +1,240 @@
+package walkers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VKCOM/noverify/src/constfold"
+	"github.com/VKCOM/noverify/src/ir"
+	"github.com/VKCOM/noverify/src/ir/irutil"
+	"github.com/VKCOM/noverify/src/solver"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/includes"
+	"github.com/vkcom/nocolor/internal/namegen"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+func (r *RootChecker) handleImportExpr(n *ir.ImportExpr) {
+	fileFunc, ok := r.resolveImport(n.Expr)
+	if !ok {
+		r.globalCtx.UnresolvedIncludes.Add(includes.Unresolved{
+			Filename: r.ctx.Filename(),
+			Line:     ir.GetPosition(n).StartLine,
+			Expr:     irutil.FmtNode(n.Expr),
+		})
+		return
+	}
+
+	r.createEdgeWithCurrent(fileFunc, edgekind.Include)
+}
+
+// resolveImport returns the file function of the included file.
+func (r *RootChecker) resolveImport(expr ir.Node) (*symbols.Function, bool) {
+	path, ok := r.evalImportPath(expr)
+	if !ok {
+		return nil, false
+	}
+
+	for _, candidate := range r.importCandidates(path) {
+		fileFunc, ok := r.findFileFunction(candidate)
+		if ok {
+			return fileFunc, true
+		}
+	}
+
+	return nil, false
+}
+
+// importCandidates returns the paths where the included file is searched.
+func (r *RootChecker) importCandidates(path string) []string {
+	if filepath.IsAbs(path) {
+		return []string{filepath.Clean(path)}
+	}
+
+	currentDir := filepath.Dir(r.ctx.Filename())
+
+	// If relative path.
+	if strings.HasPrefix(path, ".") {
+		return []string{filepath.Clean(filepath.Join(currentDir, path))}
+	}
+
+	// Otherwise, as PHP does, the file is searched in the include
+	// roots, and then in the directory of the current file.
+	candidates := make([]string, 0, len(r.globalCtx.Includes.Roots)+1)
+	for _, root := range r.globalCtx.Includes.Roots {
+		candidates = append(candidates, filepath.Join(root, path))
+	}
+
+	return append(candidates, filepath.Join(currentDir, path))
+}
+
+// evalImportPath evaluates the path of the included file.
+//
+// In addition to constant expressions, it supports '__DIR__', '__FILE__',
+// 'dirname()' calls and the user-defined path constants. Paths from magic
+// constants and user-defined constants are made absolute, since they do not
+// depend on the include roots.
+func (r *RootChecker) evalImportPath(expr ir.Node) (string, bool) {
+	switch expr := expr.(type) {
+	case *ir.ParenExpr:
+		return r.evalImportPath(expr.Expr)
+
+	case *ir.ConcatExpr:
+		left, ok := r.evalImportPath(expr.Left)
+		if !ok {
+			return "", false
+		}
+		right, ok := r.evalImportPath(expr.Right)
+		if !ok {
+			return "", false
+		}
+		return left + right, true
+
+	case *ir.MagicConstant:
+		switch expr.Value {
+		case "__DIR__":
+			return absPath(filepath.Dir(r.ctx.Filename()))
+		case "__FILE__":
+			return absPath(r.ctx.Filename())
+		}
+		return "", false
+
+	case *ir.ConstFetchExpr:
+		path, ok := r.globalCtx.Includes.Constants[strings.TrimPrefix(expr.Constant.Value, `\`)]
+		if ok {
+			return absPath(path)
+		}
+
+	case *ir.FunctionCallExpr:
+		return r.evalDirname(expr)
+	}
+
+	value := constfold.Eval(r.state, expr)
+	if !value.IsValid() {
+		return "", false
+	}
+
+	return value.ToString()
+}
+
+// evalDirname evaluates the 'dirname($path, $levels)' call.
+func (r *RootChecker) evalDirname(call *ir.FunctionCallExpr) (string, bool) {
+	name, ok := call.Function.(*ir.Name)
+	if !ok || !strings.EqualFold(strings.TrimPrefix(name.Value, `\`), "dirname") || len(call.Args) == 0 {
+		return "", false
+	}
+
+	path, ok := r.evalImportPath(call.Arg(0).Expr)
+	if !ok {
+		return "", false
+	}
+
+	levels := 1
+	if len(call.Args) > 1 {
+		value := constfold.Eval(r.state, call.Arg(1).Expr)
+		level, ok := value.ToInt()
+		if !ok || level < 1 {
+			return "", false
+		}
+		levels = int(level)
+	}
+
+	for i := 0; i < levels; i++ {
+		path = filepath.Dir(path)
+	}
+
+	return path, true
+}
+
+// findFileFunction returns the file function of the passed file.
+//
+// Files can be analyzed by both relative and absolute paths,
+// so both variants of the passed path are checked.
+func (r *RootChecker) findFileFunction(path string) (*symbols.Function, bool) {
+	fileFunc, ok := r.globalCtx.Functions.Get(namegen.FileFunction(path))
+	if ok {
+		return fileFunc, true
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, false
+	}
+
+	var otherPath string
+	if filepath.IsAbs(path) {
+		otherPath, err = filepath.Rel(wd, path)
+	} else {
+		otherPath, err = filepath.Join(wd, path), nil
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	return r.globalCtx.Functions.Get(namegen.FileFunction(otherPath))
+}
+
+func absPath(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	return abs, true
+}
+
+// handleParentsAutoload handles the classes that are
+// autoloaded when the class is declared.
+func (r *RootChecker) handleParentsAutoload(class *ir.Class) {
+	if class.Extends != nil {
+		r.handleAutoload(class.Extends.ClassName)
+	}
+	if class.Implements != nil {
+		for _, name := range class.Implements.InterfaceNames {
+			r.handleAutoload(name)
+		}
+	}
+}
+
+func (r *RootChecker) handleAutoload(class ir.Node) {
+	className, ok := solver.GetClassName(r.state, class)
+	if !ok {
+		return
+	}
+
+	r.handleClassAutoload(className)
+}
+
+// handleClassAutoload creates an edge with the file scope of the file
+// of the class, if this class is autoloaded by composer, since the
+// usage of the class leads to the execution of this file.
+func (r *RootChecker) handleClassAutoload(className string) {
+	autoload := r.globalCtx.Includes.Autoload
+	if autoload == nil {
+		return
+	}
+
+	fileFunc, ok := r.autoloadedFile(autoload, className)
+	if !ok || fileFunc.Name == namegen.FileFunction(r.ctx.Filename()) {
+		return
+	}
+
+	r.createEdgeWithCurrent(fileFunc, edgekind.Include)
+}
+
+func (r *RootChecker) autoloadedFile(autoload *includes.Autoload, className string) (*symbols.Function, bool) {
+	for _, path := range autoload.PSR4Files(className) {
+		fileFunc, ok := r.findFileFunction(path)
+		if ok {
+			return fileFunc, true
+		}
+	}
+
+	class, ok := r.globalCtx.Classes.Get(className)
+	if !ok || !autoload.InClassmap(class.Pos.Filename) {
+		return nil, false
+	}
+
+	return r.findFileFunction(class.Pos.Filename)
+}
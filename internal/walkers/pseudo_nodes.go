@@ -0,0 +1,117 @@
+package walkers
+
+import (
+	"github.com/VKCOM/noverify/src/ir"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/namegen"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+// Pseudo nodes represent superglobals and language constructs that
+// cannot be marked with @color, like 'echo' or 'eval'. They are colored
+// with the reserved colors, if these colors are used in the palette,
+// so the usual rules like "ssr php-superglobals" can be written.
+
+// pseudoNode describes one pseudo node and its reserved colors.
+type pseudoNode struct {
+	name   string
+	colors []string
+}
+
+var superglobals = []string{
+	"GLOBALS", "_SERVER", "_GET", "_POST", "_REQUEST",
+	"_COOKIE", "_FILES", "_SESSION", "_ENV",
+}
+
+var pseudoNodes = func() []pseudoNode {
+	nodes := []pseudoNode{
+		{name: "global", colors: []string{"php-global"}},
+		{name: "static", colors: []string{"php-static"}},
+		{name: "eval", colors: []string{"php-eval"}},
+		{name: "exit", colors: []string{"php-exit"}},
+		{name: "echo", colors: []string{"php-echo"}},
+		{name: "shell", colors: []string{"php-shell"}},
+	}
+
+	for _, name := range superglobals {
+		nodes = append(nodes, pseudoNode{
+			name:   "$" + name,
+			colors: []string{"php-superglobals", "php-$" + name},
+		})
+	}
+
+	return nodes
+}()
+
+// addPseudoNodes adds functions for the pseudo nodes whose reserved colors
+// are used in the palette, other pseudo nodes are not needed in the graph.
+//
+// It is called when the palette is ready, that is, on the first edge.
+func (ctx *GlobalContext) addPseudoNodes(pal *palette.Palette) {
+	for _, node := range pseudoNodes {
+		var colors palette.ColorContainer
+		for _, color := range node.colors {
+			if pal.ColorExists(color) {
+				colors.Add(pal.GetColorByName(color))
+			}
+		}
+
+		if colors.Empty() {
+			continue
+		}
+
+		ctx.Functions.Add(&symbols.Function{
+			Name:     namegen.PseudoNode(node.name),
+			Type:     symbols.PseudoNode,
+			Colors:   &colors,
+			Called:   symbols.NewEdges(),
+			CalledBy: symbols.NewEdges(),
+		})
+	}
+}
+
+// handlePseudoNode creates an edge from the current
+// function with the pseudo node for the passed node.
+func (r *RootChecker) handlePseudoNode(n ir.Node) {
+	defer func(prev ir.Node) { r.callNode = prev }(r.callNode)
+	r.callNode = n
+
+	switch n := n.(type) {
+	case *ir.SimpleVar:
+		for _, name := range superglobals {
+			if n.Name == name {
+				r.createEdgeWithPseudoNode("$" + name)
+				return
+			}
+		}
+	case *ir.GlobalStmt:
+		r.createEdgeWithPseudoNode("global")
+	case *ir.StaticStmt:
+		r.createEdgeWithPseudoNode("static")
+	case *ir.EvalExpr:
+		r.createEdgeWithPseudoNode("eval")
+	case *ir.ExitExpr:
+		r.createEdgeWithPseudoNode("exit")
+	case *ir.EchoStmt, *ir.PrintExpr:
+		r.createEdgeWithPseudoNode("echo")
+	case *ir.ShellExecExpr:
+		r.createEdgeWithPseudoNode("shell")
+	}
+}
+
+func (r *RootChecker) createEdgeWithPseudoNode(name string) {
+	r.globalCtx.pseudoNodes.Do(func() {
+		r.globalCtx.addPseudoNodes(r.palette)
+	})
+
+	node, ok := r.globalCtx.Functions.Get(namegen.PseudoNode(name))
+	if !ok {
+		// If the palette does not use the colors of the
+		// node, then there is no need to add the edge.
+		return
+	}
+
+	r.createEdgeWithCurrent(node, edgekind.Pseudo)
+}
@@ -13,7 +13,7 @@ func Register(config *linter.Config, globalCtx *GlobalContext, pal *palette.Pale
 			return NewBlockChecker(ctx, ctx.RootState()["lints-root"].(*RootChecker))
 		}
 
-		return &BlockIndexer{}
+		return NewBlockIndexer(ctx.RootState()["lints-root"].(*RootIndexer))
 	})
 
 	config.Checkers.AddRootCheckerWithCacher(globalCtx, func(ctx *linter.RootContext) linter.RootChecker {
@@ -2,10 +2,8 @@ package walkers
 
 import (
 	"fmt"
-	"path/filepath"
 	"strings"
 
-	"github.com/VKCOM/noverify/src/constfold"
 	"github.com/VKCOM/noverify/src/ir"
 	"github.com/VKCOM/noverify/src/ir/irutil"
 	"github.com/VKCOM/noverify/src/linter"
@@ -13,7 +11,8 @@ import (
 	"github.com/VKCOM/noverify/src/phpdoc"
 	"github.com/VKCOM/noverify/src/solver"
 	"github.com/VKCOM/noverify/src/types"
-	"github.com/vkcom/nocolor/internal/walkers/namegen"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/namegen"
 
 	"github.com/vkcom/nocolor/internal/palette"
 	"github.com/vkcom/nocolor/internal/symbols"
@@ -31,6 +30,27 @@ type RootChecker struct {
 
 	fileFunction *symbols.Function
 
+	// anonMethods is a stack of methods of anonymous
+	// classes, the bodies of which are being walked.
+	anonMethods []anonMethod
+
+	// callContext is the implicit colors of the
+	// current call site, like 'in-loop'.
+	callContext []string
+	// usesCallContext is set if the context colors are used in the palette.
+	usesCallContext bool
+	// contextNodes are the context nodes created
+	// for the calls from the functions of the file.
+	contextNodes map[string]*symbols.Function
+
+	// callNode is the node being handled, its position
+	// is stored as the call site of the created edges.
+	callNode ir.Node
+
+	// anonClasses are the ordinal numbers
+	// of the anonymous classes of the file.
+	anonClasses map[*ir.AnonClassExpr]int
+
 	colorTag string
 }
 
@@ -42,6 +62,9 @@ func NewRootChecker(palette *palette.Palette, globalCtx *GlobalContext, ctx *lin
 		globalCtx: globalCtx,
 		colorTag:  colorTag,
 		state:     ctx.ClassParseState(),
+
+		contextNodes:    map[string]*symbols.Function{},
+		usesCallContext: usesCallContext(palette),
 	}
 }
 
@@ -63,8 +86,8 @@ func (r *RootChecker) BeforeEnterFile() {
 			Name:     namegen.FileFunction(r.ctx.Filename()),
 			Type:     symbols.MainFunc,
 			Colors:   &palette.ColorContainer{},
-			Called:   symbols.NewFunctions(),
-			CalledBy: symbols.NewFunctions(),
+			Called:   symbols.NewEdges(),
+			CalledBy: symbols.NewEdges(),
 		}
 		return
 	}
@@ -74,36 +97,105 @@ func (r *RootChecker) BeforeEnterFile() {
 
 // AfterEnterNode
 func (r *RootChecker) AfterEnterNode(n ir.Node) {
+	r.handleCallNode(n, nil, irutil.NodePath{}, r)
+	r.handlePseudoNode(n)
+
+	// The edges created for the declarations, like the autoload
+	// ones, are located at the declarations themselves.
+	r.callNode = n
+
+	switch n := n.(type) {
+	case *ir.Root:
+		r.anonClasses = anonClassIndexes(n)
+	case *ir.ClassStmt:
+		r.handleClass(n.ClassName, n.Stmts, n.Doc, classDeclarationTypes(&n.Class))
+		r.handleParentsAutoload(&n.Class)
+	case *ir.InterfaceStmt:
+		var refs []ir.Node
+		if n.Extends != nil {
+			refs = n.Extends.InterfaceNames
+			for _, name := range n.Extends.InterfaceNames {
+				r.handleAutoload(name)
+			}
+		}
+		r.handleClass(n.InterfaceName, n.Stmts, n.Doc, refs)
+	case *ir.TraitStmt:
+		r.handleClass(n.TraitName, n.Stmts, n.Doc, classBodyTypes(n.Stmts))
+	case *ir.FunctionStmt:
+		r.handleFunction(n.FunctionName, n.Doc, n.Params, n.ReturnType)
+	}
+}
+
+// handleCallNode handles the nodes that create edges in the call graph,
+// both at the root level and inside functions.
+func (r *RootChecker) handleCallNode(n ir.Node, blockScope *meta.Scope, nodePath irutil.NodePath, v ir.Visitor) {
+	// Arguments of the call can be walked while it is handled.
+	defer func(prev ir.Node) { r.callNode = prev }(r.callNode)
+	r.callNode = n
+	r.handleTypeRefNode(n)
+
 	switch n := n.(type) {
 	case *ir.NewExpr:
-		r.handleNew(n, nil)
+		r.handleNew(n, blockScope, v)
 	case *ir.CloneExpr:
-		r.handleCloneExpr(n, nil)
+		r.handleCloneExpr(n, blockScope)
 	case *ir.FunctionCallExpr:
-		r.handleFunctionCall(n, nil, r)
+		r.handleFunctionCall(n, blockScope, v)
 	case *ir.StaticCallExpr:
-		r.handleStaticCall(n, nil)
+		r.handleStaticCall(n, blockScope)
 	case *ir.MethodCallExpr:
-		r.handleMethodCall(n, nil, r)
+		r.handleMethodCall(n, blockScope, v)
 	case *ir.NullsafeMethodCallExpr:
-		r.handleNullsafeMethodCall(n, nil, r)
+		r.handleNullsafeMethodCall(n, blockScope, v)
 	case *ir.PropertyFetchExpr:
-		r.handlePropertyFetch(n, nil, irutil.NodePath{})
+		r.handlePropertyFetch(n, blockScope, nodePath)
 	case *ir.ImportExpr:
 		r.handleImportExpr(n)
-
-	case *ir.ClassStmt:
-		r.handleClass(n.ClassName, n.Stmts, n.Doc)
-	case *ir.InterfaceStmt:
-		r.handleClass(n.InterfaceName, n.Stmts, n.Doc)
-	case *ir.TraitStmt:
-		r.handleClass(n.TraitName, n.Stmts, n.Doc)
-	case *ir.FunctionStmt:
-		r.handleFunction(n.FunctionName, n.Doc)
+	case *ir.ClassConstFetchExpr:
+		if n.ConstantName.Value != "class" {
+			r.handleAutoload(n.Class)
+		}
+	case *ir.StaticPropertyFetchExpr:
+		r.handleAutoload(n.Class)
+
+	case *ir.ArrayDimFetchExpr:
+		r.handleArrayDimFetch(n, blockScope, nodePath)
+	case *ir.IssetExpr:
+		r.handleIsset(n, blockScope)
+	case *ir.EmptyExpr:
+		r.handleEmpty(n, blockScope)
+	case *ir.UnsetStmt:
+		r.handleUnset(n, blockScope)
+	case *ir.ForeachStmt:
+		r.handleForeach(n, blockScope)
+
+	case *ir.TypeCastExpr:
+		if n.Type == "string" {
+			r.handleToString(n.Expr, blockScope)
+		}
+	case *ir.ConcatExpr:
+		r.handleToString(n.Left, blockScope)
+		r.handleToString(n.Right, blockScope)
+	case *ir.AssignConcat:
+		r.handleToString(n.Expr, blockScope)
+	case *ir.Encapsed:
+		for _, part := range n.Parts {
+			r.handleToString(part, blockScope)
+		}
+	case *ir.Heredoc:
+		for _, part := range n.Parts {
+			r.handleToString(part, blockScope)
+		}
+	case *ir.EchoStmt:
+		for _, expr := range n.Exprs {
+			r.handleToString(expr, blockScope)
+		}
+	case *ir.PrintExpr:
+		r.handleToString(n.Expr, blockScope)
 	}
 }
 
-func (r *RootChecker) handleFunction(name *ir.Identifier, doc phpdoc.Comment) {
+func (r *RootChecker) handleFunction(name *ir.Identifier, doc phpdoc.Comment, params []ir.Node, returnType ir.Node) {
 	classFQN := namegen.FunctionFQN(r.state, name.Value)
 	class, ok := r.globalCtx.Functions.Get(classFQN)
 	if !ok {
@@ -115,10 +207,18 @@ func (r *RootChecker) handleFunction(name *ir.Identifier, doc phpdoc.Comment) {
 		r.ctx.Report(name, linter.LevelError, "errorColor", err)
 	}
 
-	class.Colors.Colors = colors.Colors
+	suppressions, errs := r.suppressionsFromDoc(doc, class.HumanReadableName(), class.Pos)
+	for _, err := range errs {
+		r.ctx.Report(name, linter.LevelError, "errorColor", err)
+	}
+
+	*class.Colors = colors
+	class.Suppressions = suppressions
+
+	r.handleSignatureDependencies(class, params, returnType)
 }
 
-func (r *RootChecker) handleClassMethods(name string, stmts []ir.Node, classColors palette.ColorContainer) {
+func (r *RootChecker) handleClassMethods(name string, stmts []ir.Node, classColors palette.ColorContainer, classSuppressions []*symbols.Suppression) {
 	for _, stmt := range stmts {
 		methodNode, ok := stmt.(*ir.ClassMethodStmt)
 		if !ok {
@@ -137,6 +237,15 @@ func (r *RootChecker) handleClassMethods(name string, stmts []ir.Node, classColo
 			r.ctx.Report(methodNode.MethodName, linter.LevelError, "errorColor", err)
 		}
 
+		methodSuppressions, errs := r.suppressionsFromDoc(methodNode.Doc, method.HumanReadableName(), method.Pos)
+		for _, err := range errs {
+			r.ctx.Report(methodNode.MethodName, linter.LevelError, "errorColor", err)
+		}
+
+		// The tags of the class are shared by all its methods,
+		// so that the tag is used if any of them is used.
+		method.Suppressions = append(methodSuppressions, classSuppressions...)
+
 		if !classColors.Empty() {
 			// We need to mix the colors in the following order,
 			// first the class colors and then the method colors.
@@ -154,11 +263,18 @@ func (r *RootChecker) handleClassMethods(name string, stmts []ir.Node, classColo
 			methodColors = newColors
 		}
 
-		method.Colors.Colors = methodColors.Colors
+		// The scoped removers of the class apply to all its methods.
+		if !classColors.RemoverScope.Empty() {
+			methodColors.RemoverScope = classColors.RemoverScope.Merge(methodColors.RemoverScope)
+		}
+
+		*method.Colors = methodColors
+
+		r.handleSignatureDependencies(method, methodNode.Params, methodNode.ReturnType)
 	}
 }
 
-func (r *RootChecker) handleClass(name *ir.Identifier, stmts []ir.Node, doc phpdoc.Comment) {
+func (r *RootChecker) handleClass(name *ir.Identifier, stmts []ir.Node, doc phpdoc.Comment, refs []ir.Node) {
 	classFQN := namegen.ClassFQN(r.state, name.Value)
 	class, ok := r.globalCtx.Classes.Get(classFQN)
 	if !ok {
@@ -170,12 +286,34 @@ func (r *RootChecker) handleClass(name *ir.Identifier, stmts []ir.Node, doc phpd
 		r.ctx.Report(name, linter.LevelError, "errorColor", err)
 	}
 
-	class.Colors.Colors = colors.Colors
+	suppressions, errs := r.suppressionsFromDoc(doc, class.HumanReadableName(), class.Pos)
+	for _, err := range errs {
+		r.ctx.Report(name, linter.LevelError, "errorColor", err)
+	}
+
+	*class.Colors = colors
+	class.Suppressions = suppressions
 
-	r.handleClassMethods(classFQN, stmts, colors)
+	r.handleClassMethods(classFQN, stmts, colors, suppressions)
+	r.handleClassNode(classFQN, colors, suppressions, refs)
 }
 
 func (r *RootChecker) handlePropertyFetch(n *ir.PropertyFetchExpr, blockScope *meta.Scope, nodePath irutil.NodePath) {
+	if r.inIssetOrUnset(nodePath) {
+		return
+	}
+
+	methodName := "__get"
+	if r.inAssign(nodePath) {
+		methodName = "__set"
+	}
+
+	r.handlePropertyMagicMethod(n, blockScope, methodName)
+}
+
+// handlePropertyMagicMethod creates an edge with the passed magic method
+// for each class of the expression that does not have the property.
+func (r *RootChecker) handlePropertyMagicMethod(n *ir.PropertyFetchExpr, blockScope *meta.Scope, methodName string) {
 	var propInfo solver.FindPropertyResult
 	var propertyName string
 	var ok bool
@@ -210,11 +348,6 @@ func (r *RootChecker) handlePropertyFetch(n *ir.PropertyFetchExpr, blockScope *m
 		return
 	}
 
-	methodName := "__get"
-	if r.inAssign(nodePath) {
-		methodName = "__set"
-	}
-
 	for _, className := range classesWithoutProp {
 		fqn := namegen.Method(className, methodName)
 
@@ -223,60 +356,12 @@ func (r *RootChecker) handlePropertyFetch(n *ir.PropertyFetchExpr, blockScope *m
 			return
 		}
 
-		r.createEdgeWithCurrent(calledFunc)
+		r.createEdgeWithCurrent(calledFunc, edgekind.Magic)
 	}
 }
 
 func (r *RootChecker) handleCloneExpr(n *ir.CloneExpr, blockScope *meta.Scope) {
-	scope := blockScope
-	if scope == nil {
-		scope = r.ctx.Scope()
-	}
-
-	classTypes := solver.ExprType(scope, r.state, n.Expr)
-	classTypes.Iterate(func(classType string) {
-		if !types.IsClass(classType) {
-			return
-		}
-
-		methodInfo, ok := solver.FindMethod(r.state.Info, classType, "__clone")
-		if !ok {
-			return
-		}
-
-		methodName := namegen.Method(methodInfo.ImplName(), "__clone")
-
-		calledFunc, ok := r.globalCtx.Functions.Get(methodName)
-		if !ok {
-			return
-		}
-
-		r.createEdgeWithCurrent(calledFunc)
-	})
-}
-
-func (r *RootChecker) handleImportExpr(n *ir.ImportExpr) {
-	pathValue := constfold.Eval(r.state, n.Expr)
-	if !pathValue.IsValid() {
-		return
-	}
-
-	path, ok := pathValue.ToString()
-	if !ok {
-		return
-	}
-
-	path, ok = r.getImportAbsPath(path)
-	if !ok {
-		return
-	}
-
-	fileFunc, ok := r.globalCtx.Functions.Get(namegen.FileFunction(path))
-	if !ok {
-		return
-	}
-
-	r.createEdgeWithCurrent(fileFunc)
+	r.handleImplicitMethodCall(n.Expr, blockScope, edgekind.Magic, "", "__clone")
 }
 
 func (r *RootChecker) handleFunctionCall(n *ir.FunctionCallExpr, blockScope *meta.Scope, v ir.Visitor) {
@@ -290,40 +375,20 @@ func (r *RootChecker) handleFunctionCall(n *ir.FunctionCallExpr, blockScope *met
 		return
 	}
 
+	if fqName == `\count` && len(n.Args) != 0 {
+		r.handleImplicitMethodCall(n.Arg(0).Expr, blockScope, edgekind.Magic, `\Countable`, "count")
+	}
+
 	calledFunc, ok := r.globalCtx.Functions.Get(fqName)
 	if !ok {
 		return
 	}
 
-	r.createEdgeWithCurrent(calledFunc)
+	r.createEdgeWithCurrent(calledFunc, edgekind.Direct)
 }
 
 func (r *RootChecker) asInvokeMethod(n *ir.FunctionCallExpr, blockScope *meta.Scope) {
-	scope := blockScope
-	if scope == nil {
-		scope = r.ctx.Scope()
-	}
-
-	classTypes := solver.ExprType(scope, r.state, n.Function)
-	classTypes.Iterate(func(classType string) {
-		if !types.IsClass(classType) {
-			return
-		}
-
-		methodInfo, ok := solver.FindMethod(r.state.Info, classType, "__invoke")
-		if !ok {
-			return
-		}
-
-		methodName := namegen.Method(methodInfo.ImplName(), "__invoke")
-
-		calledFunc, ok := r.globalCtx.Functions.Get(methodName)
-		if !ok {
-			return
-		}
-
-		r.createEdgeWithCurrent(calledFunc)
-	})
+	r.handleImplicitMethodCall(n.Function, blockScope, edgekind.CallableRef, "", "__invoke")
 }
 
 func (r *RootChecker) handleStaticCall(n *ir.StaticCallExpr, blockScope *meta.Scope) {
@@ -340,7 +405,9 @@ func (r *RootChecker) handleStaticCall(n *ir.StaticCallExpr, blockScope *meta.Sc
 
 	var classType types.Map
 
-	if vr, ok := n.Class.(*ir.SimpleVar); ok {
+	if anonClass, ok := r.staticCallAnonClass(n.Class); ok {
+		classType = types.NewMap(anonClass)
+	} else if vr, ok := n.Class.(*ir.SimpleVar); ok {
 		classType = solver.ExprType(scope, r.state, vr)
 	} else {
 		className, ok := solver.GetClassName(r.state, n.Class)
@@ -348,10 +415,11 @@ func (r *RootChecker) handleStaticCall(n *ir.StaticCallExpr, blockScope *meta.Sc
 			return
 		}
 
+		r.handleClassAutoload(className)
 		classType = types.NewMap(className)
 	}
 
-	r.handleMethod(methodName, classType, true)
+	r.handleMethod(methodName, classType, edgekind.Static)
 }
 
 func (r *RootChecker) handleMethodCall(n *ir.MethodCallExpr, blockScope *meta.Scope, v ir.Visitor) {
@@ -368,7 +436,7 @@ func (r *RootChecker) handleMethodCall(n *ir.MethodCallExpr, blockScope *meta.Sc
 
 	classType := solver.ExprType(scope, r.state, n.Variable)
 
-	r.handleMethod(methodName, classType, false)
+	r.handleMethod(methodName, classType, edgekind.Method)
 
 	for _, nn := range n.Args {
 		nn.Walk(v)
@@ -389,14 +457,19 @@ func (r *RootChecker) handleNullsafeMethodCall(n *ir.NullsafeMethodCallExpr, blo
 
 	classType := solver.ExprType(scope, r.state, n.Variable)
 
-	r.handleMethod(methodName, classType, false)
+	r.handleMethod(methodName, classType, edgekind.Method)
 
 	for _, nn := range n.Args {
 		nn.Walk(v)
 	}
 }
 
-func (r *RootChecker) handleNew(n *ir.NewExpr, blockScope *meta.Scope) {
+func (r *RootChecker) handleNew(n *ir.NewExpr, blockScope *meta.Scope, v ir.Visitor) {
+	if anon, ok := n.Class.(*ir.AnonClassExpr); ok {
+		r.handleAnonClass(n, anon, v)
+		return
+	}
+
 	className, ok := solver.GetClassName(r.state, n.Class)
 	if !ok {
 		// If we cannot get the name, then we will try to find the
@@ -414,18 +487,30 @@ func (r *RootChecker) handleNew(n *ir.NewExpr, blockScope *meta.Scope) {
 				return
 			}
 
-			r.handleMethod("__construct", types.NewMap(classType), false)
+			r.handleMethod("__construct", types.NewMap(classType), edgekind.New)
+			r.handleImplicitMethods(types.NewMap(classType), edgekind.Magic, "", "__destruct")
 		})
 
 		return
 	}
 
+	r.handleClassAutoload(className)
+
 	classType := types.NewMap(className)
 
-	r.handleMethod("__construct", classType, false)
+	r.handleMethod("__construct", classType, edgekind.New)
+
+	// The object will be destroyed sooner or later,
+	// so we assume that the destructor is called here.
+	r.handleImplicitMethods(classType, edgekind.Magic, "", "__destruct")
 }
 
-func (r *RootChecker) handleMethod(methodName string, classTypes types.Map, static bool) {
+// handleMethod creates edges with the method of the passed classes.
+//
+// The kind of the call is edgekind.Static, edgekind.Method or edgekind.New.
+func (r *RootChecker) handleMethod(methodName string, classTypes types.Map, kind edgekind.Kind) {
+	static := kind == edgekind.Static
+
 	classesWithoutMethod := make([]string, 0, classTypes.Len())
 
 	classTypes.Iterate(func(classType string) {
@@ -433,6 +518,11 @@ func (r *RootChecker) handleMethod(methodName string, classTypes types.Map, stat
 			return
 		}
 
+		if namegen.IsAnonClass(classType) {
+			r.handleAnonClassMethodCall(classType, methodName, kind)
+			return
+		}
+
 		methodInfo, ok := solver.FindMethod(r.state.Info, classType, methodName)
 		if !ok || (ok && methodInfo.Info.IsFromAnnotation()) {
 			// If the method is described in the annotation for the class,
@@ -451,7 +541,7 @@ func (r *RootChecker) handleMethod(methodName string, classTypes types.Map, stat
 			return
 		}
 
-		r.createEdgeWithCurrent(calledFunc)
+		r.createEdgeWithCurrent(calledFunc, kind)
 	})
 
 	r.handleClassWithoutMethod(static, classesWithoutMethod)
@@ -471,7 +561,7 @@ func (r *RootChecker) handleClassWithoutMethod(static bool, classesWithoutMethod
 			continue
 		}
 
-		r.createEdgeWithCurrent(calledFunc)
+		r.createEdgeWithCurrent(calledFunc, edgekind.Magic)
 	}
 }
 
@@ -498,6 +588,17 @@ func (r *RootChecker) colorsFromDoc(comment phpdoc.Comment) (colors palette.Colo
 			continue
 		}
 
+		scope, ok, err := r.palette.ParseRemoverScope(colorName)
+		if ok {
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("Invalid scoped remover: %v", err))
+				continue
+			}
+
+			colors.RemoverScope = colors.RemoverScope.Merge(scope)
+			continue
+		}
+
 		if !r.palette.ColorExists(colorName) {
 			errs = append(errs, fmt.Sprintf("Color '%s' missing in palette (either a misprint or a new color that needs to be added)", colorName))
 			continue
@@ -509,24 +610,81 @@ func (r *RootChecker) colorsFromDoc(comment phpdoc.Comment) (colors palette.Colo
 	return colors, errs
 }
 
-func (r *RootChecker) getImportAbsPath(path string) (string, bool) {
-	if filepath.IsAbs(path) {
-		return filepath.Clean(path), true
+// suppressionsFromDoc returns the '@nocolor-ignore' tags of the comment
+// of the function or the class with the passed name and position.
+//
+// The tag is '@nocolor-ignore <rule or ruleset> <reason>', the names of
+// rules and rulesets may contain spaces, so the longest prefix of the value
+// that is a rule or a ruleset is the target, and the rest is the reason.
+func (r *RootChecker) suppressionsFromDoc(comment phpdoc.Comment, place string, pos meta.ElementPosition) (suppressions []*symbols.Suppression, errs []string) {
+	for _, part := range comment.Parsed {
+		p, ok := part.(*phpdoc.RawCommentPart)
+		if !ok {
+			continue
+		}
+
+		if p.Name() != symbols.SuppressionTag {
+			continue
+		}
+
+		if len(p.Params) == 0 {
+			errs = append(errs, fmt.Sprintf("An empty '@%s' tag value, expected a rule or a ruleset and the reason", p.Name()))
+			continue
+		}
+
+		var target string
+		var reason []string
+		for i := len(p.Params); i > 0; i-- {
+			candidate := strings.Join(p.Params[:i], " ")
+			if r.palette.HasRuleOrRuleset(candidate) {
+				target = candidate
+				reason = p.Params[i:]
+				break
+			}
+		}
+
+		if target == "" {
+			errs = append(errs, fmt.Sprintf("Rule or ruleset '%s' missing in palette", p.Params[0]))
+			continue
+		}
+
+		if len(reason) == 0 {
+			errs = append(errs, fmt.Sprintf("The '@%s %s' tag must have a reason why the violations are allowed", p.Name(), target))
+			continue
+		}
+
+		suppressions = append(suppressions, &symbols.Suppression{
+			Target: target,
+			Reason: strings.Join(reason, " "),
+			Place:  place,
+			Pos:    pos,
+		})
 	}
 
-	// If relative path.
-	if strings.HasPrefix(path, ".") || strings.HasPrefix(path, "..") {
-		currentFilePath := r.ctx.Filename()
-		dir := filepath.Dir(currentFilePath)
+	return suppressions, errs
+}
 
-		absPath := filepath.Clean(filepath.Join(dir, path))
-		return absPath, true
+// staticCallAnonClass returns the name of the anonymous class if
+// the static call is made via 'self' or 'static' in its method.
+func (r *RootChecker) staticCallAnonClass(class ir.Node) (string, bool) {
+	anonClass, ok := r.currentAnonClass()
+	if !ok {
+		return "", false
+	}
+
+	name, ok := class.(*ir.Name)
+	if !ok || (name.Value != "self" && name.Value != "static") {
+		return "", false
 	}
 
-	return "", false
+	return anonClass, true
 }
 
 func (r *RootChecker) getCurrentFunc() (*symbols.Function, bool) {
+	if len(r.anonMethods) != 0 {
+		return r.anonMethods[len(r.anonMethods)-1].function, true
+	}
+
 	name := r.state.CurrentFunction
 	if name == "" {
 		return r.fileFunction, true
@@ -561,14 +719,40 @@ func (r *RootChecker) getCurrentFunc() (*symbols.Function, bool) {
 	return fn, true
 }
 
-func (r *RootChecker) createEdgeWithCurrent(calledFunc *symbols.Function) {
+func (r *RootChecker) createEdgeWithCurrent(calledFunc *symbols.Function, kind edgekind.Kind) {
 	curFunc, ok := r.getCurrentFunc()
 	if !ok {
 		return
 	}
 
-	curFunc.Called.Add(calledFunc)
-	calledFunc.CalledBy.Add(curFunc)
+	site := r.callSite()
+
+	contextNode, ok := r.callContextNode(curFunc, calledFunc)
+	if ok {
+		curFunc.Called.AddCall(contextNode, kind, site)
+		contextNode.CalledBy.AddCall(curFunc, kind, site)
+		curFunc = contextNode
+	}
+
+	curFunc.Called.AddCall(calledFunc, kind, site)
+	calledFunc.CalledBy.AddCall(curFunc, kind, site)
+}
+
+// callSite returns the position of the node being handled.
+func (r *RootChecker) callSite() symbols.CallSite {
+	if r.callNode == nil {
+		return symbols.CallSite{}
+	}
+
+	pos := ir.GetPosition(r.callNode)
+	if pos == nil {
+		return symbols.CallSite{}
+	}
+
+	return symbols.CallSite{
+		Filename: r.ctx.Filename(),
+		Line:     pos.StartLine,
+	}
 }
 
 func (r *RootChecker) inAssign(nodePath irutil.NodePath) bool {
@@ -4,9 +4,10 @@ import (
 	"github.com/VKCOM/noverify/src/ir"
 	"github.com/VKCOM/noverify/src/linter"
 	"github.com/VKCOM/noverify/src/meta"
+	"github.com/VKCOM/noverify/src/solver"
+	"github.com/vkcom/nocolor/internal/namegen"
 	"github.com/vkcom/nocolor/internal/palette"
 	"github.com/vkcom/nocolor/internal/symbols"
-	"github.com/vkcom/nocolor/internal/walkers/namegen"
 )
 
 // RootIndexer is a walker that collects information about function
@@ -20,6 +21,10 @@ type RootIndexer struct {
 	palette   *palette.Palette
 	globalCtx *GlobalContext
 
+	// anonClasses are the ordinal numbers
+	// of the anonymous classes of the file.
+	anonClasses map[*ir.AnonClassExpr]int
+
 	colorTag string
 }
 
@@ -47,8 +52,8 @@ func (r *RootIndexer) BeforeEnterFile() {
 		Name:     namegen.FileFunction(r.ctx.Filename()),
 		Type:     symbols.MainFunc,
 		Colors:   &palette.ColorContainer{},
-		Called:   symbols.NewFunctions(),
-		CalledBy: symbols.NewFunctions(),
+		Called:   symbols.NewEdges(),
+		CalledBy: symbols.NewEdges(),
 	})
 }
 
@@ -77,8 +82,8 @@ func (r *RootIndexer) BeforeLeaveNode(n ir.Node) {
 				Type:     symbols.LocalFunc,
 				Pos:      meta.ElementPosition{},
 				Colors:   class.Colors,
-				Called:   symbols.NewFunctions(),
-				CalledBy: symbols.NewFunctions(),
+				Called:   symbols.NewEdges(),
+				CalledBy: symbols.NewEdges(),
 			})
 		}
 	default:
@@ -93,6 +98,13 @@ func (r *RootIndexer) BeforeEnterNode(n ir.Node) {
 	}
 
 	switch n := n.(type) {
+	case *ir.Root:
+		r.anonClasses = anonClassIndexes(n)
+	case *ir.AnonClassExpr:
+		// Only root level anonymous classes get here,
+		// the rest are handled in the BlockIndexer.
+		r.handleAnonClass(n)
+
 	case *ir.ClassStmt:
 		name := namegen.ClassFQN(r.state, n.ClassName.Value)
 
@@ -102,6 +114,7 @@ func (r *RootIndexer) BeforeEnterNode(n ir.Node) {
 			Pos:    r.getElementPos(n),
 			Colors: &palette.ColorContainer{},
 		})
+		r.indexClassNode(name, n)
 
 	case *ir.InterfaceStmt:
 		name := namegen.ClassFQN(r.state, n.InterfaceName.Value)
@@ -112,6 +125,7 @@ func (r *RootIndexer) BeforeEnterNode(n ir.Node) {
 			Pos:    r.getElementPos(n),
 			Colors: &palette.ColorContainer{},
 		})
+		r.indexClassNode(name, n)
 
 	case *ir.TraitStmt:
 		name := namegen.ClassFQN(r.state, n.TraitName.Value)
@@ -122,6 +136,7 @@ func (r *RootIndexer) BeforeEnterNode(n ir.Node) {
 			Pos:    r.getElementPos(n),
 			Colors: &palette.ColorContainer{},
 		})
+		r.indexClassNode(name, n)
 
 	case *ir.ClassMethodStmt:
 		className := r.state.CurrentClass
@@ -133,12 +148,13 @@ func (r *RootIndexer) BeforeEnterNode(n ir.Node) {
 		}
 
 		r.meta.Functions.Add(&symbols.Function{
-			Name:     methodName,
-			Type:     typ,
-			Pos:      r.getElementPos(n),
-			Colors:   &palette.ColorContainer{},
-			Called:   symbols.NewFunctions(),
-			CalledBy: symbols.NewFunctions(),
+			Name:       methodName,
+			Type:       typ,
+			Pos:        r.getElementPos(n),
+			Colors:     &palette.ColorContainer{},
+			Attributes: r.getAttributes(n.AttrGroups),
+			Called:     symbols.NewEdges(),
+			CalledBy:   symbols.NewEdges(),
 		})
 
 	case *ir.FunctionStmt:
@@ -150,16 +166,33 @@ func (r *RootIndexer) BeforeEnterNode(n ir.Node) {
 		}
 
 		r.meta.Functions.Add(&symbols.Function{
-			Name:     name,
-			Type:     typ,
-			Pos:      r.getElementPos(n),
-			Colors:   &palette.ColorContainer{},
-			Called:   symbols.NewFunctions(),
-			CalledBy: symbols.NewFunctions(),
+			Name:       name,
+			Type:       typ,
+			Pos:        r.getElementPos(n),
+			Colors:     &palette.ColorContainer{},
+			Attributes: r.getAttributes(n.AttrGroups),
+			Called:     symbols.NewEdges(),
+			CalledBy:   symbols.NewEdges(),
 		})
 	}
 }
 
+// getAttributes returns the fully qualified names of the attributes.
+func (r *RootIndexer) getAttributes(groups []*ir.AttributeGroup) []string {
+	var attrs []string
+	for _, group := range groups {
+		for _, attr := range group.Attrs {
+			name, ok := solver.GetClassName(r.state, attr.Name)
+			if !ok {
+				continue
+			}
+
+			attrs = append(attrs, name)
+		}
+	}
+	return attrs
+}
+
 func (r *RootIndexer) getElementPos(n ir.Node) meta.ElementPosition {
 	pos := ir.GetPosition(n)
 
@@ -0,0 +1,111 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pipes"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+const benchPalette = `
+performance:
+  - fast slow: potential performance leak
+  - fast allow-slow slow: ""
+rendering:
+  - ssr db: ssr must not query the database
+`
+
+// generateFunctions generates a call graph similar to the graphs of large
+// projects: most calls go "down" the layers, some go back and form
+// recursive components, and a few functions are colored.
+func generateFunctions(pal *palette.Palette, count int) *symbols.Functions {
+	rnd := rand.New(rand.NewSource(1))
+	colors := []string{"fast", "slow", "allow-slow", "ssr", "db"}
+
+	funcs := symbols.NewFunctions()
+	list := make([]*symbols.Function, 0, count)
+
+	for i := 0; i < count; i++ {
+		fun := &symbols.Function{
+			Name:     fmt.Sprintf(`\f%d`, i),
+			Type:     symbols.LocalFunc,
+			Colors:   &palette.ColorContainer{},
+			Called:   symbols.NewEdges(),
+			CalledBy: symbols.NewEdges(),
+		}
+
+		if rnd.Intn(50) == 0 {
+			fun.Colors.Add(pal.GetColorByName(colors[rnd.Intn(len(colors))]))
+		}
+
+		funcs.Add(fun)
+		list = append(list, fun)
+	}
+
+	for i, fun := range list {
+		calls := 1 + rnd.Intn(6)
+		for j := 0; j < calls; j++ {
+			var called *symbols.Function
+			if i+1 < count && rnd.Intn(20) != 0 {
+				called = list[i+1+rnd.Intn(min(count-i-1, 1000))]
+			} else {
+				called = list[rnd.Intn(count)]
+			}
+
+			fun.Called.Add(called, edgekind.Direct)
+			called.CalledBy.Add(fun, edgekind.Direct)
+		}
+	}
+
+	return funcs
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func benchmarkCheck(b *testing.B, count int, workers int) {
+	pal, err := palette.ReadPaletteFileYAML("palette.yaml", []byte(benchPalette))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	funcs := generateFunctions(pal, count)
+	pool := pipes.NewWorkers(workers)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		nodes := pipes.FunctionsToNodes(funcs, edgekind.None)
+		graphs := pipes.NodesToGraphs(nodes)
+
+		for _, graph := range graphs {
+			pipes.EraseNodesWithRemoverColor(graph)
+			pipes.CalcNextWithColor(graph, false)
+			pool.Run(func() {
+				pipes.CheckColorsInGraph(graph, pal, pipes.FindRootNodes(graph), pool)
+			})
+		}
+	}
+}
+
+func BenchmarkCheck10K(b *testing.B) {
+	benchmarkCheck(b, 10000, 1)
+}
+
+func BenchmarkCheck100K(b *testing.B) {
+	benchmarkCheck(b, 100000, 1)
+}
+
+func BenchmarkCheck100KParallel(b *testing.B) {
+	benchmarkCheck(b, 100000, runtime.NumCPU())
+}
@@ -0,0 +1,209 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/callgraph"
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pipes"
+	"github.com/vkcom/nocolor/internal/symbols"
+)
+
+const equivalencePalette = `
+first:
+  - a b: a must not reach b
+  - a allow b: ""
+  - a c b: a must not reach b through c
+second:
+  - c a d: c must not reach d through a
+  - c d: c must not reach d
+  - c allow d: ""
+third:
+  - b b: b must not reach b
+fourth:
+  - x y: x must not reach y
+`
+
+// generateSmallFunctions generates a small call graph with many
+// colored functions, recursive components and several colors on
+// some functions, so all the chains can be checked one by one.
+func generateSmallFunctions(rnd *rand.Rand, pal *palette.Palette) *symbols.Functions {
+	colors := []string{"a", "b", "c", "d", "allow", "x", "y"}
+	count := 2 + rnd.Intn(11)
+
+	funcs := symbols.NewFunctions()
+	list := make([]*symbols.Function, 0, count)
+
+	for i := 0; i < count; i++ {
+		fun := &symbols.Function{
+			Name:     fmt.Sprintf(`\f%d`, i),
+			Type:     symbols.LocalFunc,
+			Colors:   &palette.ColorContainer{},
+			Called:   symbols.NewEdges(),
+			CalledBy: symbols.NewEdges(),
+		}
+
+		for j := rnd.Intn(4) - 1; j > 0; j-- {
+			fun.Colors.Add(pal.GetColorByName(colors[rnd.Intn(len(colors))]))
+		}
+
+		funcs.Add(fun)
+		list = append(list, fun)
+	}
+
+	// Most calls go "down", so there are several roots.
+	for i, fun := range list {
+		for j := rnd.Intn(4); j > 0; j-- {
+			called := list[rnd.Intn(count)]
+			if i+1 < count && rnd.Intn(4) != 0 {
+				called = list[i+1+rnd.Intn(count-i-1)]
+			}
+
+			fun.Called.Add(called, edgekind.Direct)
+			called.CalledBy.Add(fun, edgekind.Direct)
+		}
+	}
+
+	return funcs
+}
+
+// violationKeyOf returns the rule and the first and the last colored
+// functions of the violation, see matchFromEnd in the pipes.
+func violationKeyOf(rule *palette.Rule, pal *palette.Palette, chain callgraph.Nodes) string {
+	first := chain[0]
+
+	left := len(rule.Colors)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for left > 0 && chain[i].Function.Colors.Contains(rule.Colors[left-1]) {
+			left--
+		}
+		if left == 0 {
+			first = chain[i]
+			break
+		}
+	}
+
+	return rule.String(pal) + " " + first.Function.Name + " -> " + chain[len(chain)-1].Function.Name
+}
+
+// isSubsequence checks if the colors of the rule are
+// a subsequence of the colors of the functions of the chain.
+func isSubsequence(rule *palette.Rule, chain callgraph.Nodes) bool {
+	matched := 0
+	for _, node := range chain {
+		for _, color := range node.Function.Colors.Colors {
+			if matched < len(rule.Colors) && rule.Colors[matched] == color {
+				matched++
+			}
+		}
+	}
+	return matched == len(rule.Colors)
+}
+
+// plainCheck checks every chain from the roots one by one,
+// without the automaton and the reuse of the continuations.
+func plainCheck(pal *palette.Palette, roots callgraph.Nodes) []string {
+	violations := map[string]struct{}{}
+
+	var walk func(chain callgraph.Nodes)
+	walk = func(chain callgraph.Nodes) {
+		node := chain[len(chain)-1]
+
+		for _, ruleset := range pal.Rulesets {
+			var matched *palette.Rule
+			for _, rule := range ruleset.Rules {
+				if isSubsequence(rule, chain) {
+					matched = rule
+				}
+			}
+
+			if matched != nil && matched.IsError() && node.Function.Colors.Contains(matched.Colors[len(matched.Colors)-1]) {
+				violations[violationKeyOf(matched, pal, chain)] = struct{}{}
+			}
+		}
+
+		if node.NextWithColors == nil {
+			return
+		}
+
+		for _, next := range *node.NextWithColors {
+			inChain := false
+			for _, prev := range chain {
+				inChain = inChain || prev == next
+			}
+
+			if !inChain {
+				walk(append(chain[:len(chain):len(chain)], next))
+			}
+		}
+	}
+
+	for _, root := range roots {
+		walk(callgraph.Nodes{root})
+	}
+
+	keys := make([]string, 0, len(violations))
+	for key := range violations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// reportedViolations returns the keys of the violations of the reports,
+// the chain of which is shown from the first function of the violation,
+// unless the last function breaks the rule by itself.
+func reportedViolations(pal *palette.Palette, reports []*pipes.ColorReport) []string {
+	keys := make([]string, 0, len(reports))
+	for _, report := range reports {
+		last := report.CallChain[len(report.CallChain)-1]
+
+		chain := callgraph.Nodes{report.CallChain[0], last}
+		if isSubsequence(report.Rule, callgraph.Nodes{last}) {
+			chain = callgraph.Nodes{last}
+		}
+
+		keys = append(keys, violationKeyOf(report.Rule, pal, chain))
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func TestCheckEquivalentToPlainCheck(t *testing.T) {
+	pal, err := palette.ReadPaletteFileYAML("palette.yaml", []byte(equivalencePalette))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	workers := pipes.NewWorkers(1)
+
+	for i := 0; i < 10000; i++ {
+		funcs := generateSmallFunctions(rnd, pal)
+		preciseCycles := i%2 == 0
+
+		nodes := pipes.FunctionsToNodes(funcs, edgekind.None)
+		for _, graph := range pipes.NodesToGraphs(nodes) {
+			pipes.EraseNodesWithRemoverColor(graph)
+			pipes.CalcNextWithColor(graph, preciseCycles)
+			roots := pipes.FindRootNodes(graph)
+
+			var reports []*pipes.ColorReport
+			workers.Run(func() {
+				reports = pipes.CheckColorsInGraph(graph, pal, roots, workers)
+			})
+
+			expected := plainCheck(pal, roots)
+			if have := reportedViolations(pal, reports); !reflect.DeepEqual(have, expected) {
+				t.Fatalf("graph %d (precise cycles: %v): the violations differ from the plain check:\nhave: %q\nwant: %q", i, preciseCycles, have, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package bench
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/edgekind"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func checkWithWorkers(pal *palette.Palette, count int, workers int) []string {
+	funcs := generateFunctions(pal, count)
+
+	nodes := pipes.FunctionsToNodes(funcs, edgekind.None)
+	graphs := pipes.NodesToGraphs(nodes)
+
+	pool := pipes.NewWorkers(workers)
+
+	var messages []string
+	for _, graph := range graphs {
+		pipes.EraseNodesWithRemoverColor(graph)
+		pipes.CalcNextWithColor(graph, false)
+
+		var reports []*pipes.ColorReport
+		pool.Run(func() {
+			reports = pipes.CheckColorsInGraph(graph, pal, pipes.FindRootNodes(graph), pool)
+		})
+
+		for _, report := range reports {
+			messages = append(messages, report.Message)
+		}
+	}
+
+	sort.Strings(messages)
+	return messages
+}
+
+func TestCheckParallel(t *testing.T) {
+	pal, err := palette.ReadPaletteFileYAML("palette.yaml", []byte(benchPalette))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The number of workers is limited by GOMAXPROCS.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	expected := checkWithWorkers(pal, 2000, 1)
+	if len(expected) == 0 {
+		t.Fatal("no reports found")
+	}
+
+	for _, workers := range []int{2, 8} {
+		for i := 0; i < 2; i++ {
+			messages := checkWithWorkers(pal, 2000, workers)
+			if !reflect.DeepEqual(messages, expected) {
+				t.Fatalf("reports with %d workers differ from the sequential check:\n%v\nexpected:\n%v", workers, messages, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestAnonClass(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddNamedFile("anon.php", `<?php
+class Base {
+  /** @color red */
+  public function baseMethod() {}
+}
+
+/** @color red */
+function redFunc() {}
+
+/** @color green */
+function f1() {
+  $a = new class {
+    public function __construct() { redFunc(); }
+  };
+}
+
+/** @color green */
+function f2() {
+  $a = new class extends Base {
+    public function __construct() { $this->baseMethod(); }
+  };
+}
+
+/** @color green */
+function f3() {
+  $a = new /** @color red */ class {};
+}
+
+/** @color green */
+function f4() {
+  $a = new class {
+    /** @color red */
+    public function method() {}
+
+    public function __construct() {
+      $this->method();
+      self::staticMethod();
+    }
+
+    /** @color red */
+    public static function staticMethod() {}
+  };
+}
+
+$a = new class {
+  /** @color green */
+  public function method() {
+    $b = new class {
+      public function __construct() { redFunc(); }
+    };
+  }
+};
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f1@green -> class@anonymous (anon.php:12)::__construct -> redFunc@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f2@green -> class@anonymous (anon.php:19)::__construct -> Base::baseMethod@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f3@green -> class@anonymous (anon.php:26)::__construct (default autogenerated)@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f4@green -> class@anonymous (anon.php:31)::__construct -> class@anonymous (anon.php:31)::method@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f4@green -> class@anonymous (anon.php:31)::__construct -> class@anonymous (anon.php:31)::staticMethod@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+class@anonymous (anon.php:45)::method@green -> class@anonymous (anon.php:48)::__construct -> redFunc@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestAnonClassColors(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddNamedFile("anon.php", `<?php
+/** @color red */
+function redFunc() {}
+
+function f() {
+  return /** @color green */ new class {
+    public function method() { redFunc(); }
+
+    /** @color internals */
+    public function internals() {}
+  };
+}
+
+/** @color module */
+function g() {
+  $a = new class {
+    public function __construct() {
+      $b = new /** @color internals */ class {};
+    }
+  };
+}
+
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+class@anonymous (anon.php:6)::method@green -> redFunc@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestAnonClassInFileScope(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+loops:
+  - in-loop red: red functions must not be called in loops
+`
+	suite.AddNamedFile("main.php", `<?php
+/** @color red */
+function redFunc() {}
+
+$a = new class {
+  public function method() {}
+};
+$a->method();
+
+while (true) {
+  redFunc();
+}
+`)
+
+	// The code after the anonymous class at the root level is still handled.
+	suite.Expect = []string{
+		`
+in-loop red => red functions must not be called in loops
+  This color rule is broken, call chain:
+<in-loop>@in-loop -> redFunc@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestCallContext(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+fast:
+  - fast in-loop db: fast code must not query the database in loops
+transactions:
+  - transaction in-try in-loop db: transactions must not retry queries in loops
+`
+	suite.AddNamedFile("main.php", `<?php
+/** @color db */
+function query() {}
+
+/** @color fast */
+function queryInFor() {
+  for ($i = 0; $i < 10; $i++) {
+    query();
+  }
+}
+
+/** @color fast */
+function queryInForeach($ids) {
+  foreach ($ids as $id) {
+    if ($id) {
+      query();
+    }
+  }
+}
+
+/** @color fast */
+function queryInWhileCond() {
+  while (query()) {}
+}
+
+/** @color fast */
+function queryInDoWhile() {
+  do {
+    query();
+  } while (false);
+}
+
+/** @color fast */
+function queryIndirectlyInLoop() {
+  while (true) {
+    queryOnce();
+  }
+}
+
+function queryOnce() {
+  query();
+}
+
+/** @color fast */
+function queryBeforeLoop() {
+  foreach (query() as $id) {}
+  for ($i = query(); false;) {}
+  query();
+}
+
+/** @color fast */
+function queryInAssignedClosure() {
+  $f = function() {
+    while (true) {
+      query();
+    }
+  };
+}
+
+/** @color fast */
+function queryInClosureInLoop() {
+  while (true) {
+    $f = function() {
+      query();
+    };
+  }
+}
+
+/** @color transaction */
+function retryInTransaction() {
+  try {
+    while (true) {
+      query();
+    }
+  } catch (Exception $e) {}
+}
+
+/** @color transaction */
+function retryInCatch() {
+  try {} catch (Exception $e) {
+    while (true) {
+      query();
+    }
+  }
+}
+
+/** @color transaction */
+function loopOutsideOfTry() {
+  while (true) {
+    try {
+      query();
+    } finally {}
+  }
+}
+`)
+
+	suite.Expect = []string{
+		`
+fast in-loop db => fast code must not query the database in loops
+  This color rule is broken, call chain:
+queryInFor@fast -> <in-loop>@in-loop -> query@db
+  Call kinds: direct -> direct
+`,
+		`
+fast in-loop db => fast code must not query the database in loops
+  This color rule is broken, call chain:
+queryInForeach@fast -> <in-loop>@in-loop -> query@db
+`,
+		`
+fast in-loop db => fast code must not query the database in loops
+  This color rule is broken, call chain:
+queryInWhileCond@fast -> <in-loop>@in-loop -> query@db
+`,
+		`
+fast in-loop db => fast code must not query the database in loops
+  This color rule is broken, call chain:
+queryInDoWhile@fast -> <in-loop>@in-loop -> query@db
+`,
+		`
+fast in-loop db => fast code must not query the database in loops
+  This color rule is broken, call chain:
+queryIndirectlyInLoop@fast -> <in-loop>@in-loop -> queryOnce -> query@db
+`,
+		`
+fast in-loop db => fast code must not query the database in loops
+  This color rule is broken, call chain:
+queryInAssignedClosure@fast -> <in-loop>@in-loop -> query@db
+`,
+		`
+transaction in-try in-loop db => transactions must not retry queries in loops
+  This color rule is broken, call chain:
+retryInTransaction@transaction -> <in-try in-loop>@in-try@in-loop -> query@db
+`,
+	}
+
+	suite.RunAndMatch()
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestCallSites(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddNamedFile("main.php", `<?php
+/** @color green */
+function green() {
+  echo 1;
+  helper(
+    1
+  );
+  helper(2);
+}
+
+/** @color green */
+function inLoop() {
+  foreach ([1, 2] as $_) {
+    new Foo;
+  }
+}
+`)
+	suite.AddNamedFile("lib.php", `<?php
+function helper($x) {
+  if ($x) {
+    redFunc();
+  }
+}
+
+/** @color red */
+function redFunc() {}
+
+class Foo {
+  /** @color red */
+  public function __construct() {}
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+green@green -> helper -> redFunc@red
+  Call kinds: direct -> direct
+  Call sites: main.php:5 -> lib.php:4
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+inLoop@green -> Foo::__construct@red
+  Call kinds: new
+  Call sites: main.php:14
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestCallSitesOfDeclarations(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = dependenciesPalette
+	suite.Dependencies = true
+	suite.AddNamedFile("main.php", `<?php
+/** @color infrastructure */
+class Db {}
+
+/** @color domain */
+class User
+  extends Db {}
+`)
+
+	suite.Expect = []string{
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+User::class@domain -> Db::class@infrastructure
+  Call kinds: type-ref
+  Call sites: main.php:6
+`,
+	}
+
+	suite.RunAndMatch()
+}
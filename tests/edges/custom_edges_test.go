@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestCustomEdges(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.Edges = `
+events:
+- from: EventDispatcher::dispatch
+  to-attribute: AsEventListener
+
+routing:
+- from: Router::handle
+  to: App\Controllers\*::*Action
+`
+	suite.AddFile(`<?php
+namespace App\Events;
+
+#[\Attribute]
+class AsEventListener {}
+`)
+	suite.AddFile(`<?php
+namespace App\Controllers;
+
+class UserController {
+  /** @color red */
+  public function showAction() {}
+
+  /** @color red */
+  public function helper() {}
+}
+`)
+	suite.AddFile(`<?php
+use App\Events\AsEventListener;
+
+class EventDispatcher {
+  public function dispatch($event) {}
+}
+
+class Router {
+  public function handle($uri) {}
+}
+
+class Listener {
+  #[AsEventListener]
+  /** @color red */
+  public function onEvent($event) {}
+
+  /** @color red */
+  public function notListener($event) {}
+}
+
+/** @color green */
+function f1(EventDispatcher $dispatcher) {
+  $dispatcher->dispatch("event");
+}
+
+/** @color green */
+function f2(Router $router) {
+  $router->handle("/");
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f1@green -> EventDispatcher::dispatch -> Listener::onEvent@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f2@green -> Router::handle -> App\Controllers\UserController::showAction@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+const dependenciesPalette = `
+layers:
+  - domain infrastructure: domain depends on infrastructure
+`
+
+func TestDependencies(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = dependenciesPalette
+	suite.Dependencies = true
+	suite.AddNamedFile("infrastructure.php", `<?php
+namespace Infrastructure;
+
+/** @color infrastructure */
+class Db {}
+
+/** @color infrastructure */
+interface Connection {}
+
+/** @color infrastructure */
+class DbException extends \Exception {}
+
+/** @color infrastructure */
+trait Logging {}
+`)
+	suite.AddNamedFile("domain.php", `<?php
+namespace Domain;
+
+use Infrastructure\Db;
+use Infrastructure\Connection;
+use Infrastructure\DbException;
+use Infrastructure\Logging;
+
+/** @color domain */
+class User extends Db {}
+
+/** @color domain */
+class Order implements Connection {}
+
+/** @color domain */
+class Product {
+  use Logging;
+}
+
+/** @color domain */
+class Cart {
+  private ?Db $db = null;
+}
+
+/** @color domain */
+interface Repository extends Connection {}
+
+/** @color domain */
+function params(Db $db): void {}
+
+/** @color domain */
+function returns(): ?Connection { return null; }
+
+/** @color domain */
+function catches() {
+  try {
+  } catch (DbException $e) {
+  }
+}
+
+/** @color domain */
+function checksType($a) {
+  return $a instanceof Db;
+}
+
+/** @color domain */
+function className() {
+  return Db::class;
+}
+
+/** @color domain */
+function closure() {
+  return function(Db $db) {};
+}
+
+/** @color domain */
+function anonClass() {
+  return new class implements Connection {};
+}
+`)
+
+	suite.Expect = []string{
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\User::class@domain -> Infrastructure\Db::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\Order::class@domain -> Infrastructure\Connection::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\Product::class@domain -> Infrastructure\Logging::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\Cart::class@domain -> Infrastructure\Db::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\Repository::class@domain -> Infrastructure\Connection::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\params@domain -> Infrastructure\Db::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\returns@domain -> Infrastructure\Connection::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\catches@domain -> Infrastructure\DbException::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\checksType@domain -> Infrastructure\Db::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\className@domain -> Infrastructure\Db::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\closure@domain -> Infrastructure\Db::class@infrastructure
+  Call kinds: type-ref
+`,
+		`
+domain infrastructure => domain depends on infrastructure
+  This color rule is broken, call chain:
+Domain\anonClass@domain -> Infrastructure\Connection::class@infrastructure
+  Call kinds: type-ref
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestDependenciesDisabledByDefault(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = dependenciesPalette
+	suite.AddNamedFile("main.php", `<?php
+/** @color infrastructure */
+class Db {}
+
+/** @color domain */
+class User extends Db {}
+
+/** @color domain */
+function params(Db $db) {
+  return $db instanceof Db;
+}
+`)
+
+	suite.Expect = []string{}
+
+	suite.RunAndMatch()
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestEdgeKinds(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddNamedFile("main.php", `<?php
+class Foo {
+  /** @color red */
+  public function method() {}
+
+  /** @color red */
+  public static function staticMethod() {}
+
+  /** @color red */
+  public function __construct() {}
+
+  /** @color red */
+  public function __toString() { return ""; }
+
+  /** @color red */
+  public function __invoke() {}
+}
+
+/** @color red */
+function redFunc() {}
+
+/** @color green */
+function direct() { redFunc(); }
+
+/** @color green */
+function method(Foo $foo) { $foo->method(); }
+
+/** @color green */
+function staticMethod() { Foo::staticMethod(); }
+
+/** @color green */
+function newFoo() { new Foo; }
+
+/** @color green */
+function magic(Foo $foo) { echo $foo; }
+
+/** @color green */
+function callableRef(Foo $foo) { $foo(); }
+
+/** @color green */
+function includeFile() {
+  require_once "./other.php";
+}
+`)
+	suite.AddNamedFile("other.php", `<?php
+redFunc();
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+direct@green -> redFunc@red
+  Call kinds: direct
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+method@green -> Foo::method@red
+  Call kinds: method
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+staticMethod@green -> Foo::staticMethod@red
+  Call kinds: static
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+newFoo@green -> Foo::__construct@red
+  Call kinds: new
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+magic@green -> Foo::__toString@red
+  Call kinds: magic
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+callableRef@green -> Foo::__invoke@red
+  Call kinds: callable-ref
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+includeFile@green -> file 'other.php' scope -> redFunc@red
+  Call kinds: include -> direct
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestExcludeEdgesInRuleset(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+red group:
+  - exclude-edges: include
+  - red green: red green mixing
+internals group:
+  - internals: call internals
+`
+	suite.AddNamedFile("main.php", `<?php
+/** @color green */
+function greenFunc() {}
+
+/** @color internals */
+function internalsFunc() {}
+
+/** @color red */
+function redFunc() {
+  require_once "./other.php";
+}
+`)
+	suite.AddNamedFile("other.php", `<?php
+greenFunc();
+internalsFunc();
+`)
+
+	suite.Expect = []string{
+		`
+internals => call internals
+  This color rule is broken, call chain:
+redFunc -> file 'other.php' scope -> internalsFunc@internals
+  Call kinds: include -> direct
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestExcludeEdgesForAllRulesets(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.ExcludeEdges = "magic,callable-ref"
+	suite.AddNamedFile("main.php", `<?php
+class Foo {
+  /** @color red */
+  public function __toString() { return ""; }
+
+  /** @color red */
+  public function __invoke() {}
+
+  /** @color red */
+  public function method() {}
+}
+
+/** @color green */
+function f(Foo $foo) {
+  echo $foo;
+  $foo();
+  $foo->method();
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f@green -> Foo::method@red
+  Call kinds: method
+`,
+	}
+
+	suite.RunAndMatch()
+}
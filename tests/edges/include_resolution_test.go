@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/includes"
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestIncludeResolution(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.IncludeRoots = []string{"lib"}
+	suite.PathConstants = map[string]string{"ROOT": "app"}
+	suite.AddNamedFile("app/index.php", `<?php
+/** @color green */
+function includeRoot() {
+  require_once 'helpers.php';
+}
+
+/** @color green */
+function pathConstant() {
+  require_once ROOT . '/config.php';
+}
+
+/** @color green */
+function dirConstant() {
+  require_once __DIR__ . '/config.php';
+}
+
+/** @color green */
+function dirnameCall() {
+  require_once dirname(__FILE__, 2) . '/lib/helpers.php';
+}
+
+function unresolved($name) {
+  require_once $name . '.php';
+  require_once UNKNOWN . '/file.php';
+}
+`)
+	suite.AddNamedFile("lib/helpers.php", `<?php
+redFunc();
+`)
+	suite.AddNamedFile("app/config.php", `<?php
+redFunc();
+`)
+	suite.AddNamedFile("red.php", `<?php
+/** @color red */
+function redFunc() {}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+includeRoot@green -> file 'lib/helpers.php' scope -> redFunc@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+pathConstant@green -> file 'app/config.php' scope -> redFunc@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+dirConstant@green -> file 'app/config.php' scope -> redFunc@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+dirnameCall@green -> file 'lib/helpers.php' scope -> redFunc@red
+`,
+	}
+
+	suite.RunAndMatch()
+
+	unresolved := suite.UnresolvedIncludes()
+	expected := []includes.Unresolved{
+		{Filename: "app/index.php", Line: 23, Expr: "$name . '.php'"},
+		{Filename: "app/index.php", Line: 24, Expr: "UNKNOWN . '/file.php'"},
+	}
+
+	if len(unresolved) != len(expected) {
+		t.Fatalf("unexpected unresolved includes: %v", unresolved)
+	}
+	for i := range expected {
+		if unresolved[i] != expected[i] {
+			t.Errorf("unexpected unresolved include: expected %v, got %v", expected[i], unresolved[i])
+		}
+	}
+}
+
+func TestComposerAutoload(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.Composer = `{
+  "autoload": {
+    "psr-4": {"App\\": "src/"},
+    "classmap": ["legacy/"]
+  }
+}`
+	suite.AddNamedFile("src/Models/User.php", `<?php
+namespace App\Models;
+
+redFunc();
+
+class User {
+  const TABLE = "users";
+}
+`)
+	suite.AddNamedFile("legacy/old.php", `<?php
+redFunc();
+
+class OldHelper {
+  public static function help() {}
+}
+`)
+	suite.AddNamedFile("red.php", `<?php
+/** @color red */
+function redFunc() {}
+`)
+	suite.AddNamedFile("index.php", `<?php
+use App\Models\User;
+
+/** @color green */
+function newUser() {
+  return new User;
+}
+
+/** @color green */
+function classConstant() {
+  return User::TABLE;
+}
+
+/** @color green */
+function staticCall() {
+  \OldHelper::help();
+}
+
+/** @color green */
+function classReference() {
+  return User::class;
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+newUser@green -> file 'src/Models/User.php' scope -> redFunc@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+classConstant@green -> file 'src/Models/User.php' scope -> redFunc@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+staticCall@green -> file 'legacy/old.php' scope -> redFunc@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
@@ -716,3 +716,322 @@ f@green -> Zoo::__set@red
 
 	suite.RunAndMatch()
 }
+
+func TestToStringMagicMethod(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(`<?php
+class Foo {
+  /**
+   * @color red
+   */
+  public function __toString() { return ""; }
+}
+
+/**
+ * @color green
+ */
+function f1(Foo $foo) {
+  $a = (string)$foo;
+}
+
+/**
+ * @color green
+ */
+function f2(Foo $foo) {
+  $a = "value: " . $foo;
+}
+
+/**
+ * @color green
+ */
+function f3(Foo $foo) {
+  $a = "value: $foo";
+}
+
+/**
+ * @color green
+ */
+function f4(Foo $foo) {
+  echo $foo;
+}
+
+/**
+ * @color green
+ */
+function f5(Foo $foo) {
+  $a = (int)$foo;
+  $b = "value: " . 10;
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f1@green -> Foo::__toString@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f2@green -> Foo::__toString@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f3@green -> Foo::__toString@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f4@green -> Foo::__toString@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestDestructMagicMethod(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(`<?php
+class Foo {
+  /**
+   * @color red
+   */
+  public function __destruct() {}
+}
+
+/**
+ * @color green
+ */
+function f1() {
+  $a = new Foo;
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f1@green -> Foo::__destruct@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestIssetUnsetMagicMethods(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(`<?php
+class Foo {
+  public int $defined = 10;
+
+  /**
+   * @color red
+   */
+  public function __get(string $name) { return 1; }
+
+  /**
+   * @color red
+   */
+  public function __isset(string $name) { return true; }
+
+  /**
+   * @color red
+   */
+  public function __unset(string $name) {}
+}
+
+/**
+ * @color green
+ */
+function f1(Foo $foo) {
+  $a = isset($foo->undef);
+  $b = isset($foo->defined);
+}
+
+/**
+ * @color green
+ */
+function f2(Foo $foo) {
+  unset($foo->undef);
+  unset($foo->defined);
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f1@green -> Foo::__isset@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+f2@green -> Foo::__unset@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestArrayAccessMethods(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(`<?php
+class Foo implements \ArrayAccess {
+  /**
+   * @color red
+   */
+  public function offsetGet($offset) { return 1; }
+
+  /**
+   * @color red
+   */
+  public function offsetSet($offset, $value) {}
+
+  /**
+   * @color red
+   */
+  public function offsetExists($offset) { return true; }
+
+  /**
+   * @color red
+   */
+  public function offsetUnset($offset) {}
+}
+
+class NotArrayAccess {
+  /**
+   * @color red
+   */
+  public function offsetGet($offset) { return 1; }
+}
+
+/**
+ * @color green
+ */
+function get(Foo $foo, NotArrayAccess $other) {
+  $a = $foo["key"];
+  $b = $other["key"];
+}
+
+/**
+ * @color green
+ */
+function set(Foo $foo) {
+  $foo["key"] = 10;
+}
+
+/**
+ * @color green
+ */
+function exists(Foo $foo) {
+  $a = isset($foo["key"]);
+}
+
+/**
+ * @color green
+ */
+function remove(Foo $foo) {
+  unset($foo["key"]);
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+get@green -> Foo::offsetGet@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+set@green -> Foo::offsetSet@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+exists@green -> Foo::offsetExists@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+remove@green -> Foo::offsetUnset@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestIteratorAndCountableMethods(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(`<?php
+class Items implements \IteratorAggregate, \Countable {
+  /**
+   * @color red
+   */
+  public function getIterator() { return new \ArrayIterator([]); }
+
+  /**
+   * @color red
+   */
+  public function count() { return 0; }
+}
+
+class Cursor implements \Iterator {
+  public function rewind() {}
+  public function valid() { return false; }
+  public function key() { return 0; }
+  public function next() {}
+
+  /**
+   * @color red
+   */
+  public function current() { return 0; }
+}
+
+/**
+ * @color green
+ */
+function iterate(Items $items, Cursor $cursor) {
+  foreach ($items as $item) {}
+  foreach ($cursor as $item) {}
+}
+
+/**
+ * @color green
+ */
+function countItems(Items $items) {
+  return count($items);
+}
+`)
+
+	suite.Expect = []string{
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+iterate@green -> Items::getIterator@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+iterate@green -> Cursor::current@red
+`,
+		`
+green red => calling red from green is prohibited
+  This color rule is broken, call chain:
+countItems@green -> Items::count@red
+`,
+	}
+
+	suite.RunAndMatch()
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestPseudoNodes(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+ssr:
+  - ssr php-superglobals: ssr code must not read superglobals
+  - ssr allow-cookie php-$_COOKIE: ""
+pure:
+  - pure php-global: pure functions must not use globals
+  - pure php-static: pure functions must not use static state
+fast:
+  - fast php-exit: fast code must not exit
+  - fast php-eval: fast code must not use eval
+  - fast php-echo: fast code must not echo
+  - fast php-shell: fast code must not run shell commands
+`
+	suite.AddNamedFile("main.php", `<?php
+/** @color ssr */
+function readGet() {
+  return $_GET["id"];
+}
+
+function readPost() {
+  return $_POST["id"];
+}
+
+/** @color ssr */
+function readPostIndirectly() {
+  return readPost();
+}
+
+/**
+ * @color ssr
+ * @color allow-cookie
+ */
+function readCookie() {
+  return $_COOKIE["id"];
+}
+
+/** @color pure */
+function usesGlobal() {
+  global $x;
+  return $x;
+}
+
+/** @color pure */
+function usesStatic() {
+  static $counter = 0;
+  return ++$counter;
+}
+
+/** @color fast */
+function exits() {
+  die("error");
+}
+
+/** @color fast */
+function evals() {
+  eval("return 1;");
+}
+
+/** @color fast */
+function prints() {
+  print "a";
+}
+
+/** @color fast */
+function runsShell() {
+  return `+"`ls`"+`;
+}
+
+/** @color fast */
+function includesTemplate() {
+  require_once "./template.php";
+}
+`)
+	suite.AddNamedFile("template.php", `<?php
+echo "<html>";
+`)
+
+	suite.Expect = []string{
+		`
+ssr php-superglobals => ssr code must not read superglobals
+  This color rule is broken, call chain:
+readGet@ssr -> <$_GET>@php-superglobals
+  Call kinds: pseudo
+`,
+		`
+ssr php-superglobals => ssr code must not read superglobals
+  This color rule is broken, call chain:
+readPostIndirectly@ssr -> readPost -> <$_POST>@php-superglobals
+  Call kinds: direct -> pseudo
+`,
+		`
+pure php-global => pure functions must not use globals
+  This color rule is broken, call chain:
+usesGlobal@pure -> <global>@php-global
+`,
+		`
+pure php-static => pure functions must not use static state
+  This color rule is broken, call chain:
+usesStatic@pure -> <static>@php-static
+`,
+		`
+fast php-exit => fast code must not exit
+  This color rule is broken, call chain:
+exits@fast -> <exit>@php-exit
+`,
+		`
+fast php-eval => fast code must not use eval
+  This color rule is broken, call chain:
+evals@fast -> <eval>@php-eval
+`,
+		`
+fast php-echo => fast code must not echo
+  This color rule is broken, call chain:
+prints@fast -> <echo>@php-echo
+`,
+		`
+fast php-shell => fast code must not run shell commands
+  This color rule is broken, call chain:
+runsShell@fast -> <shell>@php-shell
+`,
+		`
+fast php-echo => fast code must not echo
+  This color rule is broken, call chain:
+includesTemplate@fast -> file 'template.php' scope -> <echo>@php-echo
+  Call kinds: include -> pseudo
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestPseudoNodesInFileScope(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+ssr:
+  - ssr php-superglobals: ssr code must not read superglobals
+fast:
+  - fast php-echo: fast code must not echo
+  - fast php-eval: fast code must not use eval
+`
+	suite.AddNamedFile("main.php", `<?php
+/** @color ssr */
+function render() {
+  require_once "./template.php";
+}
+
+/** @color fast */
+function handler() {
+  require_once "./legacy.php";
+}
+`)
+	suite.AddNamedFile("template.php", `<?php
+$id = $_GET["id"];
+`)
+	suite.AddNamedFile("legacy.php", `<?php
+echo "a";
+eval("return 1;");
+`)
+
+	suite.Expect = []string{
+		`
+fast php-echo => fast code must not echo
+  This color rule is broken, call chain:
+handler@fast -> file 'legacy.php' scope -> <echo>@php-echo
+`,
+		`
+fast php-eval => fast code must not use eval
+  This color rule is broken, call chain:
+handler@fast -> file 'legacy.php' scope -> <eval>@php-eval
+`,
+		`
+ssr php-superglobals => ssr code must not read superglobals
+  This color rule is broken, call chain:
+render@ssr -> file 'template.php' scope -> <$_GET>@php-superglobals
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestPseudoNodesOnlyForPaletteColors(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+fast:
+  - fast php-echo: fast code must not echo
+`
+	suite.AddFile(`<?php
+/** @color fast */
+function f() {
+  echo $_GET["id"];
+  eval("return 1;");
+}
+`)
+
+	suite.Expect = []string{
+		`
+fast php-echo => fast code must not echo
+  This color rule is broken, call chain:
+f@fast -> <echo>@php-echo
+`,
+	}
+
+	suite.RunAndMatch()
+
+	for _, name := range []string{"<echo>", "<$_GET>", "<eval>"} {
+		_, ok := suite.Functions().Get(name)
+		if want := name == "<echo>"; ok != want {
+			t.Errorf("pseudo node %s exists: %v, want %v", name, ok, want)
+		}
+	}
+}
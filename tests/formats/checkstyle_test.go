@@ -0,0 +1,59 @@
+package formats
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func TestCheckstyle(t *testing.T) {
+	reports, _ := runFormatsSuite(t)
+	reports = append(reports, newLinterReport())
+
+	data, err := xml.Marshal(pipes.NewCheckstyleReport(reports))
+	if err != nil {
+		t.Fatalf("cannot marshal Checkstyle: %v", err)
+	}
+
+	var checkstyle pipes.CheckstyleReport
+	if err := xml.Unmarshal(data, &checkstyle); err != nil {
+		t.Fatalf("cannot unmarshal Checkstyle: %v", err)
+	}
+
+	var errors []string
+	for _, file := range checkstyle.Files {
+		for _, e := range file.Errors {
+			errors = append(errors, file.Name+":"+strconv.Itoa(e.Line)+" "+e.Source)
+		}
+	}
+	expectErrors := []string{
+		"main.php:2 nocolor.errorColor",
+		"main.php:3 nocolor.highload no-highload",
+		"main.php:8 nocolor.ssr has-db",
+	}
+	if !equalStrings(errors, expectErrors) {
+		t.Errorf("unexpected errors:\nwant: %q\nhave: %q", expectErrors, errors)
+	}
+
+	message := checkstyle.Files[0].Errors[1].Message
+	if !strings.Contains(message, "handler@highload -> helper -> slow@no-highload") {
+		t.Errorf("the message does not contain the call chain: %s", message)
+	}
+}
+
+func TestCheckstyleFileScope(t *testing.T) {
+	reports, _ := runFileScopeSuite(t)
+
+	checkstyle := pipes.NewCheckstyleReport(reports)
+	if len(checkstyle.Files) != 1 || len(checkstyle.Files[0].Errors) != 1 {
+		t.Fatalf("expected 1 file with 1 error, got %+v", checkstyle.Files)
+	}
+
+	file := checkstyle.Files[0]
+	if file.Name != "index.php" || file.Errors[0].Line != 4 {
+		t.Errorf("expected the error at index.php:4, got %s:%d", file.Name, file.Errors[0].Line)
+	}
+}
@@ -0,0 +1,117 @@
+package formats
+
+import (
+	"testing"
+
+	"github.com/VKCOM/noverify/src/linter"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+const formatsPalette = `
+highload:
+  - highload no-highload: calling no-highload function from highload function
+  - highload allow-hh no-highload: ""
+ssr:
+  - ssr has-db: ssr must not access the database
+`
+
+// newFormatsSuite returns the suite for the code with two violations,
+// one of which is reported through a transparent function.
+func newFormatsSuite(t *testing.T) *linttest.Suite {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = formatsPalette
+	suite.AddNamedFile("main.php", `<?php
+/** @color highload */
+function handler() {
+  helper();
+}
+
+/** @color ssr */
+function render() { query(); }
+`)
+	suite.AddNamedFile("lib.php", `<?php
+function helper() {
+  slow();
+}
+
+/** @color no-highload */
+function slow() {}
+
+/** @color has-db */
+function query() {}
+`)
+
+	return suite
+}
+
+// runFormatsSuite checks the code of newFormatsSuite.
+func runFormatsSuite(t *testing.T) ([]*pipes.GeneralReport, *palette.Palette) {
+	suite := newFormatsSuite(t)
+
+	reports := suite.RunLinter()
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+
+	return toGeneralReports(reports), suite.ParsedPalette()
+}
+
+func toGeneralReports(reports []*pipes.ColorReport) []*pipes.GeneralReport {
+	generalReports := make([]*pipes.GeneralReport, 0, len(reports))
+	for _, report := range reports {
+		generalReports = append(generalReports, pipes.NewGeneralReportFromColorReport(report))
+	}
+
+	return generalReports
+}
+
+// newLinterReport returns a report of the stage of collecting colors,
+// such reports cannot be produced by the Suite.
+func newLinterReport() *pipes.GeneralReport {
+	return pipes.NewGeneralReportFromLinterReport(&linter.Report{
+		CheckName: "errorColor",
+		Context:   "@color unknown",
+		Message:   "Color 'unknown' is not in the palette",
+		Filename:  "main.php",
+		Line:      2,
+	})
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// runFileScopeSuite checks the code with the violation
+// the chain of which starts at the file scope.
+func runFileScopeSuite(t *testing.T) ([]*pipes.GeneralReport, *palette.Palette) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+superglobals:
+  - php-superglobals: superglobals must not be used
+`
+	suite.AddNamedFile("index.php", `<?php
+$x = 1;
+
+echo $_GET["x"];
+`)
+
+	reports := suite.RunLinter()
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+
+	return toGeneralReports(reports), suite.ParsedPalette()
+}
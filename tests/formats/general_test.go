@@ -0,0 +1,12 @@
+package formats
+
+import "testing"
+
+func TestGeneralReportFileScope(t *testing.T) {
+	reports, _ := runFileScopeSuite(t)
+
+	report := reports[0]
+	if report.File != "index.php" || report.Line != 4 {
+		t.Errorf("expected the position index.php:4, got %s:%d", report.File, report.Line)
+	}
+}
@@ -0,0 +1,147 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/graphexport"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func buildGraph(t *testing.T, opts graphexport.Options) (*graphexport.Graph, error) {
+	suite := newFormatsSuite(t)
+	suite.RunLinter()
+
+	nodes := pipes.FunctionsToNodes(suite.Functions(), 0)
+	return graphexport.Build(nodes, suite.ParsedPalette(), opts)
+}
+
+// edgesOf returns the edges of the graph like 'handler -> slow (through 1)'.
+func edgesOf(graph *graphexport.Graph) []string {
+	var edges []string
+	for _, edge := range graph.Edges {
+		line := edge.From.Name + " -> " + edge.To.Name
+		if edge.Through != 0 {
+			line += " (through " + strconv.Itoa(edge.Through) + ")"
+		}
+		edges = append(edges, line)
+	}
+	return edges
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func TestGraphAll(t *testing.T) {
+	graph, err := buildGraph(t, graphexport.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"handler -> helper", "helper -> slow", "render -> query"}
+	if !equalStrings(sortedStrings(edgesOf(graph)), expected) {
+		t.Errorf("unexpected edges %v, expected %v", edgesOf(graph), expected)
+	}
+}
+
+func TestGraphCollapseTransparent(t *testing.T) {
+	graph, err := buildGraph(t, graphexport.Options{CollapseTransparent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"handler -> slow (through 1)", "render -> query"}
+	if !equalStrings(sortedStrings(edgesOf(graph)), expected) {
+		t.Errorf("unexpected edges %v, expected %v", edgesOf(graph), expected)
+	}
+
+	for _, node := range graph.Nodes {
+		if len(node.Colors) == 0 {
+			t.Errorf("transparent function %s is exported", node.Name)
+		}
+	}
+}
+
+func TestGraphNeighborhood(t *testing.T) {
+	graph, err := buildGraph(t, graphexport.Options{Function: `\helper`, Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"handler -> helper", "helper -> slow"}
+	if !equalStrings(sortedStrings(edgesOf(graph)), expected) {
+		t.Errorf("unexpected edges %v, expected %v", edgesOf(graph), expected)
+	}
+
+	_, err = buildGraph(t, graphexport.Options{Function: "unknown", Depth: 1})
+	if err == nil || !strings.Contains(err.Error(), "function 'unknown' is not found") {
+		t.Errorf("expected the error of the unknown function, got %v", err)
+	}
+}
+
+func TestGraphComponent(t *testing.T) {
+	graph, err := buildGraph(t, graphexport.Options{Component: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Edges) == 0 || len(graph.Edges) == 3 {
+		t.Errorf("expected the edges of one component, got %v", edgesOf(graph))
+	}
+
+	_, err = buildGraph(t, graphexport.Options{Component: 10})
+	if err == nil || !strings.Contains(err.Error(), "component 10 does not exist") {
+		t.Errorf("expected the error of the missing component, got %v", err)
+	}
+}
+
+func TestGraphFormats(t *testing.T) {
+	graph, err := buildGraph(t, graphexport.Options{CollapseTransparent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(dot.String(), "digraph nocolor {") || !strings.Contains(dot.String(), `[label="direct (through 1)"]`) {
+		t.Errorf("unexpected DOT output:\n%s", dot.String())
+	}
+
+	var graphML bytes.Buffer
+	if err := graph.WriteGraphML(&graphML); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(graphML.String(), `<graph id="nocolor" edgedefault="directed">`) || !strings.Contains(graphML.String(), `<data key="through">1</data>`) {
+		t.Errorf("unexpected GraphML output:\n%s", graphML.String())
+	}
+
+	var data bytes.Buffer
+	if err := graph.WriteJSON(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Nodes []struct {
+			ID     string   `json:"id"`
+			Colors []string `json:"colors"`
+		} `json:"nodes"`
+		Edges []struct {
+			Kinds   []string `json:"kinds"`
+			Through int      `json:"through"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal(data.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Nodes) != 4 || len(decoded.Edges) != 2 {
+		t.Errorf("expected 4 nodes and 2 edges, got %d and %d", len(decoded.Nodes), len(decoded.Edges))
+	}
+}
@@ -0,0 +1,66 @@
+package formats
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func TestHTML(t *testing.T) {
+	suite := newFormatsSuite(t)
+	reports := toGeneralReports(suite.RunLinter())
+	reports = append(reports, newLinterReport())
+
+	var buf bytes.Buffer
+	err := pipes.WriteHTMLReport(&buf, reports, suite.ParsedPalette(), suite.Functions(), pipes.HTMLOptions{
+		Version:   "1.0.0",
+		SourceURL: "https://example.com/blob/master/{file}#L{line}",
+	})
+	if err != nil {
+		t.Fatalf("cannot write HTML: %v", err)
+	}
+	page := buf.String()
+
+	// The page must be standalone.
+	external := regexp.MustCompile(`<link|<img|src=|@import|url\((?:[^#]|$)`)
+	if loc := external.FindStringIndex(page); loc != nil {
+		t.Errorf("the page has an external asset: %s", page[loc[0]:loc[1]+40])
+	}
+
+	expect := []string{
+		// Violations grouped by rulesets.
+		`<h2 id="ruleset-0">highload</h2>`,
+		`<h2 id="ruleset-1">ssr</h2>`,
+		`Found 2 violations.`,
+		// Nodes of the graph.
+		`<text x="16" y="21">handler</text>`,
+		`>helper</text>`,
+		`>@no-highload</text>`,
+		// Hops with links to the call sites.
+		`<li>helper (direct call) <a href="https://example.com/blob/master/main.php#L4">main.php:4</a></li>`,
+		`<li>slow@no-highload (direct call) <a href="https://example.com/blob/master/lib.php#L3">lib.php:3</a></li>`,
+		// Errors in the color tags.
+		`Color &#39;unknown&#39; is not in the palette`,
+		// Table of colored functions.
+		`<td class="mono">query</td>`,
+		`<td class="mono">render</td>`,
+		`<input id="search"`,
+	}
+	for _, want := range expect {
+		if !strings.Contains(page, want) {
+			t.Errorf("the page does not contain %q", want)
+		}
+	}
+
+	// Transparent functions are not in the table of colored functions.
+	if strings.Contains(page, `<td class="mono">helper</td>`) {
+		t.Errorf("the table of colored functions contains a transparent function")
+	}
+
+	if t.Failed() {
+		t.Log(page)
+	}
+}
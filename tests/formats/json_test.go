@@ -0,0 +1,156 @@
+package formats
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func validateJSONReport(t *testing.T, report *pipes.JSONReport) {
+	t.Helper()
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("cannot marshal JSON report: %v", err)
+	}
+
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewBytesLoader(pipes.JSONReportSchema),
+		gojsonschema.NewBytesLoader(data),
+	)
+	if err != nil {
+		t.Fatalf("cannot validate JSON report: %v", err)
+	}
+
+	for _, desc := range result.Errors() {
+		t.Errorf("JSON report does not match the schema: %s", desc)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	reports, pal := runFormatsSuite(t)
+	reports = append(reports, newLinterReport())
+
+	report := pipes.NewJSONReport(reports, pal, "1.0.0")
+	validateJSONReport(t, report)
+
+	if report.SchemaVersion != pipes.JSONSchemaVersion || report.Tool.Version != "1.0.0" {
+		t.Errorf("unexpected version: schema %d, tool %q", report.SchemaVersion, report.Tool.Version)
+	}
+	if report.Palette.Hash != pal.Hash || len(pal.Hash) != 64 {
+		t.Errorf("unexpected palette hash %q", report.Palette.Hash)
+	}
+
+	summary := report.Summary
+	if summary.Violations != 2 || summary.ColorErrors != 1 {
+		t.Errorf("expected 2 violations and 1 color error, got %d and %d", summary.Violations, summary.ColorErrors)
+	}
+	for _, ruleset := range summary.Rulesets {
+		if ruleset.Violations != 1 {
+			t.Errorf("expected 1 violation of the %q ruleset, got %d", ruleset.Name, ruleset.Violations)
+		}
+	}
+
+	var highload *pipes.JSONReportEntry
+	for _, entry := range report.Reports {
+		if entry.Rule == "highload no-highload" {
+			highload = entry
+		}
+	}
+	if highload == nil {
+		t.Fatalf("no report for the 'highload no-highload' rule")
+	}
+	if highload.Ruleset != "highload" || highload.Type != pipes.JSONViolation || highload.Fingerprint == "" {
+		t.Errorf("unexpected report: %+v", highload)
+	}
+
+	data, err := json.Marshal(highload.Chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectChain := `[` +
+		`{"name":"handler","file":"main.php","line":3,"colors":["highload"]},` +
+		`{"name":"helper","file":"lib.php","line":2,"colors":[],"kind":"direct","call_site":{"file":"main.php","line":4}},` +
+		`{"name":"slow","file":"lib.php","line":7,"colors":["no-highload"],"kind":"direct","call_site":{"file":"lib.php","line":3}}` +
+		`]`
+	if string(data) != expectChain {
+		t.Errorf("unexpected chain:\nwant: %s\nhave: %s", expectChain, data)
+	}
+
+	last := report.Reports[len(report.Reports)-1]
+	if last.Type != pipes.JSONColorError || last.Check != "errorColor" || last.Context != "@color unknown" {
+		t.Errorf("unexpected color error: %+v", last)
+	}
+}
+
+func TestJSONWithoutReports(t *testing.T) {
+	_, pal := runFormatsSuite(t)
+
+	report := pipes.NewJSONReport(nil, pal, "")
+	validateJSONReport(t, report)
+
+	if len(report.Summary.Rulesets) != 2 || report.Summary.Violations != 0 {
+		t.Errorf("unexpected summary: %+v", report.Summary)
+	}
+}
+
+func TestJSONLegacy(t *testing.T) {
+	reports, _ := runFormatsSuite(t)
+
+	data, err := json.Marshal(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fields of the array of the previous releases must not change.
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(decoded))
+	}
+	for _, field := range []string{"rule", "call-chain", "call-kinds", "call-sites", "message", "fingerprint", "context", "file", "line"} {
+		if _, ok := decoded[0][field]; !ok {
+			t.Errorf("no field %q in the report: %s", field, data)
+		}
+	}
+
+	// The reports of the collecting colors stage have no chain.
+	data, err = json.Marshal([]*pipes.GeneralReport{newLinterReport()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"rule":"","call-chain":null,"message":"Color 'unknown' is not in the palette","context":"@color unknown","file":"main.php","line":2}]`
+	if string(data) != expected {
+		t.Errorf("unexpected color error:\nwant: %s\nhave: %s", expected, data)
+	}
+}
+
+func TestJSONFileScope(t *testing.T) {
+	reports, pal := runFileScopeSuite(t)
+
+	report := pipes.NewJSONReport(reports, pal, "1.0.0")
+	validateJSONReport(t, report)
+
+	entry := report.Reports[0]
+	if entry.File != "index.php" || entry.Line != 4 {
+		t.Errorf("expected the position index.php:4, got %s:%d", entry.File, entry.Line)
+	}
+
+	data, err := json.Marshal(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var legacy []map[string]interface{}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if legacy[0]["file"] != "index.php" || legacy[0]["line"] != 4.0 {
+		t.Errorf("expected the position index.php:4 in the legacy report, got %s", data)
+	}
+}
@@ -0,0 +1,84 @@
+package formats
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/palette"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func marshalJUnit(t *testing.T, reports []*pipes.GeneralReport, pal *palette.Palette) *pipes.JUnitReport {
+	t.Helper()
+
+	data, err := xml.Marshal(pipes.NewJUnitReport(reports, pal))
+	if err != nil {
+		t.Fatalf("cannot marshal JUnit: %v", err)
+	}
+
+	var junit pipes.JUnitReport
+	if err := xml.Unmarshal(data, &junit); err != nil {
+		t.Fatalf("cannot unmarshal JUnit: %v", err)
+	}
+
+	return &junit
+}
+
+func junitCases(junit *pipes.JUnitReport) []string {
+	var cases []string
+	for _, suite := range junit.Suites {
+		for _, c := range suite.Cases {
+			status := "passed"
+			if c.Failure != nil {
+				status = "failed"
+			}
+			cases = append(cases, suite.Name+": "+c.Name+" "+status)
+		}
+	}
+	return cases
+}
+
+func TestJUnit(t *testing.T) {
+	reports, pal := runFormatsSuite(t)
+	reports = append(reports, newLinterReport())
+
+	junit := marshalJUnit(t, reports, pal)
+
+	expectCases := []string{
+		"highload: highload no-highload failed",
+		"ssr: ssr has-db failed",
+		"errorColor: errorColor failed",
+	}
+	if cases := junitCases(junit); !equalStrings(cases, expectCases) {
+		t.Errorf("unexpected test cases:\nwant: %q\nhave: %q", expectCases, cases)
+	}
+	if junit.Tests != 3 || junit.Failures != 3 {
+		t.Errorf("expected 3 tests and 3 failures, got %d and %d", junit.Tests, junit.Failures)
+	}
+
+	failure := junit.Suites[0].Cases[0].Failure
+	if failure.Message != "calling no-highload function from highload function" {
+		t.Errorf("unexpected failure message: %s", failure.Message)
+	}
+	if !strings.Contains(failure.Text, "handler@highload -> helper -> slow@no-highload\n  Call kinds: direct -> direct\n  Call sites: main.php:4 -> lib.php:3") {
+		t.Errorf("the failure does not contain the call chain:\n%s", failure.Text)
+	}
+}
+
+func TestJUnitWithoutReports(t *testing.T) {
+	_, pal := runFormatsSuite(t)
+
+	junit := marshalJUnit(t, nil, pal)
+
+	expectCases := []string{
+		"highload: highload passed",
+		"ssr: ssr passed",
+	}
+	if cases := junitCases(junit); !equalStrings(cases, expectCases) {
+		t.Errorf("unexpected test cases:\nwant: %q\nhave: %q", expectCases, cases)
+	}
+	if junit.Tests != 2 || junit.Failures != 0 {
+		t.Errorf("expected 2 tests and no failures, got %d and %d", junit.Tests, junit.Failures)
+	}
+}
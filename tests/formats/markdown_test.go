@@ -0,0 +1,119 @@
+package formats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func TestMarkdown(t *testing.T) {
+	reports, pal := runFormatsSuite(t)
+	reports = append(reports, newLinterReport())
+
+	var buf bytes.Buffer
+	if err := pipes.WriteMarkdownReport(&buf, reports, pal); err != nil {
+		t.Fatalf("cannot write Markdown: %v", err)
+	}
+	text := buf.String()
+
+	if strings.Contains(text, "\x1b[") {
+		t.Errorf("the report has ANSI sequences:\n%s", text)
+	}
+
+	expect := []string{
+		"Found 2 violations. Found 1 errors in the color tags.",
+		// Summary table.
+		"| Ruleset | Violations |\n| --- | ---: |\n| highload | 1 |\n| ssr | 1 |\n",
+		// Color errors.
+		"- `main.php:2` Color 'unknown' is not in the palette: `@color unknown`\n",
+		// Mermaid flowchart of the chain through the transparent function.
+		"**`highload no-highload`** — calling no-highload function from highload function",
+		"```mermaid\nflowchart LR\n" +
+			"  n0[\"handler<br/>@highload\"]\n" +
+			"  style n0 fill:",
+		"  n1[\"helper\"]\n  n0 -->|direct| n1\n" +
+			"  n2[\"slow<br/>@no-highload\"]\n",
+		"  n1 -->|direct| n2\n```\n",
+		// Collapsible details.
+		"<details>\n<summary>handler → slow at main.php:3</summary>",
+		"Call sites: main.php:4 -> lib.php:3",
+		"</details>\n",
+	}
+	for _, s := range expect {
+		if !strings.Contains(text, s) {
+			t.Errorf("the report does not contain %q:\n%s", s, text)
+		}
+	}
+
+	if strings.Count(text, "```mermaid") != 2 {
+		t.Errorf("expected 2 flowcharts:\n%s", text)
+	}
+}
+
+func TestMarkdownNoViolations(t *testing.T) {
+	_, pal := runFormatsSuite(t)
+
+	var buf bytes.Buffer
+	if err := pipes.WriteMarkdownReport(&buf, nil, pal); err != nil {
+		t.Fatalf("cannot write Markdown: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "No violations found.") || !strings.Contains(buf.String(), "| highload | 0 |") {
+		t.Errorf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestMarkdownContextNodes(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+fast:
+  - fast in-loop db: querying the database in a loop is slow
+`
+	suite.AddNamedFile("main.php", `<?php
+/** @color fast */
+function handler() {
+  foreach ([1, 2] as $id) {
+    load($id);
+  }
+}
+
+/** @color db */
+function load($id) {}
+`)
+
+	reports := toGeneralReports(suite.RunLinter())
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+
+	var buf bytes.Buffer
+	if err := pipes.WriteMarkdownReport(&buf, reports, suite.ParsedPalette()); err != nil {
+		t.Fatalf("cannot write Markdown: %v", err)
+	}
+	text := buf.String()
+
+	// The context node must not be taken for an HTML tag by Mermaid.
+	if !strings.Contains(text, `  n1["#lt;in-loop#gt;<br/>@in-loop"]`) {
+		t.Errorf("the context node is not escaped:\n%s", text)
+	}
+	if strings.Contains(text, `["<in-loop>`) {
+		t.Errorf("the report has an unescaped context node:\n%s", text)
+	}
+}
+
+func TestMarkdownFileScope(t *testing.T) {
+	reports, pal := runFileScopeSuite(t)
+
+	var buf bytes.Buffer
+	if err := pipes.WriteMarkdownReport(&buf, reports, pal); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "at index.php:4</summary>") {
+		t.Errorf("the summary does not contain the position of the chain:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,141 @@
+package formats
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func validateSARIF(t *testing.T, log *pipes.SARIFLog) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(log)
+	if err != nil {
+		t.Fatalf("cannot marshal SARIF: %v", err)
+	}
+
+	schemaPath, err := filepath.Abs("testdata/sarif-schema-2.1.0.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewReferenceLoader("file://"+filepath.ToSlash(schemaPath)),
+		gojsonschema.NewBytesLoader(data),
+	)
+	if err != nil {
+		t.Fatalf("cannot validate SARIF: %v", err)
+	}
+
+	for _, desc := range result.Errors() {
+		t.Errorf("SARIF does not match the schema: %s", desc)
+	}
+
+	return data
+}
+
+func TestSARIF(t *testing.T) {
+	reports, pal := runFormatsSuite(t)
+	reports = append(reports, newLinterReport())
+
+	log := pipes.NewSARIFLog(reports, pal, "1.0.0")
+	validateSARIF(t, log)
+
+	driver := log.Runs[0].Tool.Driver
+
+	var ruleIDs []string
+	for _, rule := range driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID+" ("+rule.FullDescription.Text+")")
+	}
+	expectRules := []string{
+		"highload no-highload (highload)",
+		"highload allow-hh no-highload (highload)",
+		"ssr has-db (ssr)",
+		"errorColor (Report erroneous color in phpdoc)",
+	}
+	if !equalStrings(ruleIDs, expectRules) {
+		t.Errorf("unexpected rules:\nwant: %q\nhave: %q", expectRules, ruleIDs)
+	}
+
+	results := log.Runs[0].Results
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	byRule := map[string]*pipes.SARIFResult{}
+	for _, result := range results {
+		if driver.Rules[result.RuleIndex].ID != result.RuleID {
+			t.Errorf("result of rule %q has index of rule %q", result.RuleID, driver.Rules[result.RuleIndex].ID)
+		}
+		byRule[result.RuleID] = result
+	}
+
+	highload := byRule["highload no-highload"]
+	if highload == nil {
+		t.Fatalf("no result for the 'highload no-highload' rule")
+	}
+	if highload.PartialFingerprints[pipes.SARIFFingerprintKey] != reports[0].Fingerprint &&
+		highload.PartialFingerprints[pipes.SARIFFingerprintKey] != reports[1].Fingerprint {
+		t.Errorf("unexpected fingerprint %q", highload.PartialFingerprints[pipes.SARIFFingerprintKey])
+	}
+
+	var flow []string
+	for _, location := range highload.CodeFlows[0].ThreadFlows[0].Locations {
+		physical := location.Location.PhysicalLocation
+		flow = append(flow, location.Location.Message.Text+" "+physical.ArtifactLocation.URI+":"+strconv.Itoa(physical.Region.StartLine))
+	}
+	expectFlow := []string{
+		"handler@highload main.php:4",
+		"helper lib.php:3",
+		"slow@no-highload lib.php:7",
+	}
+	if !equalStrings(flow, expectFlow) {
+		t.Errorf("unexpected thread flow:\nwant: %q\nhave: %q", expectFlow, flow)
+	}
+
+	errorColor := byRule["errorColor"]
+	if errorColor == nil {
+		t.Fatalf("no result for the 'errorColor' check")
+	}
+	if errorColor.Locations[0].PhysicalLocation.ArtifactLocation.URI != "main.php" {
+		t.Errorf("unexpected location of the errorColor result: %+v", errorColor.Locations[0].PhysicalLocation)
+	}
+}
+
+func TestSARIFWithoutReports(t *testing.T) {
+	_, pal := runFormatsSuite(t)
+
+	data := validateSARIF(t, pipes.NewSARIFLog(nil, pal, ""))
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	results := raw["runs"].([]interface{})[0].(map[string]interface{})["results"]
+	if results == nil || len(results.([]interface{})) != 0 {
+		t.Errorf("expected an empty list of results, got %v", results)
+	}
+}
+
+func TestSARIFFileScope(t *testing.T) {
+	reports, pal := runFileScopeSuite(t)
+
+	log := pipes.NewSARIFLog(reports, pal, "1.0.0")
+	validateSARIF(t, log)
+
+	result := log.Runs[0].Results[0]
+	if len(result.Locations) != 1 || result.Locations[0].PhysicalLocation == nil {
+		t.Fatalf("expected the physical location of the result, got %+v", result.Locations)
+	}
+
+	physical := result.Locations[0].PhysicalLocation
+	if physical.ArtifactLocation.URI != "index.php" || physical.Region == nil || physical.Region.StartLine != 4 {
+		t.Errorf("expected the location index.php:4, got %+v", physical)
+	}
+}
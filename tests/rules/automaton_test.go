@@ -0,0 +1,113 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/palette"
+)
+
+const automatonPalette = `
+performance:
+  - fast slow: potential performance leak
+  - fast allow-slow slow: ""
+chains:
+  - api mid curl: api must not perform curl requests through mid
+exceptions:
+  - ssr allow-db db: ""
+  - ssr db: ssr must not query the database
+`
+
+// matchChain returns the rules that decide for the chain of the
+// functions with the passed colors by the names of their rulesets.
+func matchChain(t *testing.T, chain ...[]string) map[string]string {
+	pal, err := palette.ReadPaletteFileYAML("palette.yaml", []byte(automatonPalette))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	automaton := palette.NewAutomaton(pal)
+	state := automaton.Start()
+	for _, names := range chain {
+		colors := make([]palette.Color, 0, len(names))
+		for _, name := range names {
+			colors = append(colors, pal.GetColorByName(name))
+		}
+		state = automaton.Next(state, colors)
+	}
+
+	matched := map[string]string{}
+	for i := 0; i < automaton.Rulesets(); i++ {
+		if rule, ok := automaton.MatchedRule(state, i); ok {
+			matched[pal.Rulesets[i].Name] = rule.String(pal)
+		}
+	}
+
+	return matched
+}
+
+func TestAutomaton(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain [][]string
+		want  map[string]string
+	}{
+		{
+			name:  "empty chain",
+			chain: nil,
+			want:  map[string]string{},
+		},
+		{
+			name:  "subsequence",
+			chain: [][]string{{"api"}, {"fast"}, {"mid"}, {}, {"curl"}},
+			want:  map[string]string{"chains": "api mid curl"},
+		},
+		{
+			name:  "wrong order",
+			chain: [][]string{{"mid"}, {"api"}, {"curl"}},
+			want:  map[string]string{},
+		},
+		{
+			name:  "several colors on one node",
+			chain: [][]string{{"api", "mid"}, {"curl"}},
+			want:  map[string]string{"chains": "api mid curl"},
+		},
+		{
+			name:  "several colors on one node in the wrong order",
+			chain: [][]string{{"mid", "api"}, {"curl"}},
+			want:  map[string]string{},
+		},
+		{
+			name:  "all colors on one node",
+			chain: [][]string{{"fast", "slow"}},
+			want:  map[string]string{"performance": "fast slow"},
+		},
+		{
+			name:  "last rule wins for the error",
+			chain: [][]string{{"fast"}, {"allow-slow"}, {"slow"}},
+			want:  map[string]string{"performance": "fast allow-slow slow"},
+		},
+		{
+			name:  "exception after the error",
+			chain: [][]string{{"fast"}, {"slow"}, {"allow-slow"}, {"slow"}},
+			want:  map[string]string{"performance": "fast allow-slow slow"},
+		},
+		{
+			name:  "last rule wins for the exception",
+			chain: [][]string{{"ssr"}, {"allow-db"}, {"db"}},
+			want:  map[string]string{"exceptions": "ssr db"},
+		},
+		{
+			name:  "several rulesets",
+			chain: [][]string{{"ssr", "fast"}, {"db", "slow"}},
+			want:  map[string]string{"performance": "fast slow", "exceptions": "ssr db"},
+		},
+	}
+
+	for _, test := range tests {
+		have := matchChain(t, test.chain...)
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("%s: want %q, have %q", test.name, test.want, have)
+		}
+	}
+}
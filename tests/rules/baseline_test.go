@@ -0,0 +1,172 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/baseline"
+	"github.com/vkcom/nocolor/internal/linttest"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func baselineReports(t *testing.T, code string) []*pipes.ColorReport {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(code)
+
+	return suite.RunLinter()
+}
+
+func TestBaseline(t *testing.T) {
+	legacy := baselineReports(t, `<?php
+/** @color api */
+function api() { hasCurl(); api2(); }
+
+/** @color api2 */
+function api2() { hasCurl(); }
+
+/** @color has-curl */
+function hasCurl() {}
+
+api();
+`)
+	if len(legacy) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(legacy))
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.New(legacy).WriteToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	accepted, err := baseline.OpenFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accepted.Entries) != 2 || accepted.Entries[0].Ruleset == "" || accepted.Entries[0].First == "" {
+		t.Fatalf("unexpected entries of the baseline: %+v", accepted.Entries)
+	}
+
+	// The lines are shifted, one violation is fixed and a new one is added.
+	current := baselineReports(t, `<?php
+
+
+/** @color api */
+function api() { hasCurl(); }
+
+/** @color api2 */
+function api2() { hasCurl(); }
+
+/** @color api2 */
+function newApi() { hasCurl(); }
+
+/** @color has-curl */
+function hasCurl() {}
+
+api();
+api2();
+newApi();
+`)
+
+	left, suppressed, fixed := accepted.Filter(current)
+	if suppressed != 2 {
+		t.Errorf("expected 2 suppressed reports, got %d", suppressed)
+	}
+	if len(left) != 1 || left[0].CallChain[0].Function.HumanReadableName() != "newApi" {
+		t.Errorf("expected only the report from newApi, got %v", left)
+	}
+	if len(fixed) != 0 {
+		t.Errorf("expected no fixed entries, got %+v", fixed)
+	}
+
+	fixedOnly := baselineReports(t, `<?php
+/** @color api */
+function api() { hasCurl(); }
+
+/** @color has-curl */
+function hasCurl() {}
+
+api();
+`)
+
+	left, suppressed, fixed = accepted.Filter(fixedOnly)
+	if len(left) != 0 || suppressed != 1 || len(fixed) != 1 {
+		t.Errorf("expected 1 suppressed report and 1 fixed entry, got %d, %d and %d", len(left), suppressed, len(fixed))
+	}
+}
+
+func TestBaselineStableNames(t *testing.T) {
+	const stableNamesPalette = `
+loops:
+  - in-loop db: queries must not be in loops
+fast:
+  - fast db: fast code must not query the database
+`
+
+	run := func(name, code string) []*pipes.ColorReport {
+		suite := linttest.NewSuite(t)
+
+		suite.Palette = stableNamesPalette
+		suite.AddNamedFile(name, code)
+
+		return suite.RunLinter()
+	}
+
+	reports := run("src/main.php", `<?php
+/** @color db */
+function query() {}
+
+for ($i = 0; $i < 10; $i++) { query(); }
+
+$handler = new class {
+  /** @color fast */
+  public function handle() { query(); }
+};
+$handler->handle();
+`)
+
+	var ends []string
+	for _, report := range reports {
+		first, last := report.ColoredEnds()
+		ends = append(ends, first+" => "+last)
+	}
+	sort.Strings(ends)
+
+	expected := []string{
+		"<in-loop> file 'src/main.php' scope -> query => query",
+		"class@anonymous#1 (src/main.php)::handle => query",
+	}
+	if !reflect.DeepEqual(ends, expected) {
+		t.Fatalf("expected the ends %q, got %q", expected, ends)
+	}
+
+	// The lines are shifted and the file is passed by the absolute path.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shifted := run(filepath.Join(wd, "src", "main.php"), `<?php
+
+
+/** @color db */
+function query() {}
+
+$handler = new class {
+  /** @color fast */
+  public function handle() { query(); }
+};
+$handler->handle();
+
+for ($i = 0; $i < 10; $i++) { query(); }
+`)
+
+	left, suppressed, fixed := baseline.New(reports).Filter(shifted)
+	if len(left) != 0 || suppressed != 2 || len(fixed) != 0 {
+		t.Errorf("expected 2 suppressed reports, got %d left, %d suppressed and %d fixed", len(left), suppressed, len(fixed))
+	}
+}
@@ -0,0 +1,221 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/diff"
+	"github.com/vkcom/nocolor/internal/linttest"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func diffReports(t *testing.T) []*pipes.ColorReport {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddNamedFile("main.php", `<?php
+/** @color highload */
+function handler() {
+  helper();
+}
+
+/** @color ssr */
+function render() {
+  query();
+}
+`)
+	suite.AddNamedFile("lib.php", `<?php
+function helper() {
+  slow();
+}
+
+/** @color no-highload */
+function slow() {}
+
+/** @color has-db-access */
+function query() {}
+`)
+
+	reports := suite.RunLinter()
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+
+	return reports
+}
+
+func filterByDiff(t *testing.T, reports []*pipes.ColorReport, patch string) []string {
+	changes, err := diff.Parse(strings.NewReader(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var firsts []string
+	for _, report := range changes.Filter(reports) {
+		firsts = append(firsts, report.CallChain[0].Function.HumanReadableName())
+	}
+
+	return firsts
+}
+
+func TestDiffFilter(t *testing.T) {
+	reports := diffReports(t)
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected []string
+	}{
+		{
+			name: "transparent function of the chain in another file",
+			patch: `diff --git a/lib.php b/lib.php
+index 1111111..2222222 100644
+--- a/lib.php
++++ b/lib.php
+@@ -2,3 +2,4 @@
+ function helper() {
++  // The helper is changed.
+   slow();
+ }
+`,
+			expected: []string{"handler"},
+		},
+		{
+			name: "removed lines",
+			patch: `--- a/main.php
++++ b/main.php
+@@ -8,3 +8,2 @@
+ function render() {
+-  log();
+   query();
+`,
+			expected: []string{"render"},
+		},
+		{
+			name: "unrelated change",
+			patch: `--- a/lib.php
++++ b/lib.php
+@@ -11,0 +12,2 @@
++
++function unrelated() {}
+`,
+			expected: nil,
+		},
+		{
+			name: "new and removed files",
+			patch: `--- /dev/null
++++ b/other.php
+@@ -0,0 +1 @@
++<?php
+--- a/old.php
++++ /dev/null
+@@ -1,2 +0,0 @@
+-<?php
+-function old() {}
+`,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		got := filterByDiff(t, reports, test.patch)
+		if !equalNames(got, test.expected) {
+			t.Errorf("%s: expected the violations from %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestDiffParse(t *testing.T) {
+	changes, err := diff.Parse(strings.NewReader(`--- src/a.php	2021-08-20 11:23:10.000000000 +0300
++++ src/a.php	2021-08-21 11:23:10.000000000 +0300
+@@ -1,3 +1,4 @@
+ <?php
++--- not a header
+ function a() {}
+-
++function b() {}
+\ No newline at end of file
+@@ -10 +11 @@
+-x
++y
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ranges := changes.Files["src/a.php"]
+	expected := []diff.Range{{From: 2, To: 2}, {From: 4, To: 4}, {From: 11, To: 11}}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected ranges %v, got %v", expected, ranges)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Errorf("expected ranges %v, got %v", expected, ranges)
+		}
+	}
+
+	_, err = diff.Parse(strings.NewReader("--- a/a.php\n+++ b/a.php\n@@ invalid @@\n"))
+	if err == nil || !strings.Contains(err.Error(), "invalid hunk header") {
+		t.Errorf("expected the error of the invalid hunk header, got %v", err)
+	}
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDiffSamePathEnds(t *testing.T) {
+	changes, err := diff.Parse(strings.NewReader(`--- a/a.php
++++ b/a.php
+@@ -1 +1 @@
+-x
++y
+--- a/lib/a.php
++++ b/lib/a.php
+@@ -5 +5 @@
+-x
++y
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The longest matching path is taken.
+	for i := 0; i < 10; i++ {
+		if changes.Contains("src/lib/a.php", 1, 1) || !changes.Contains("src/lib/a.php", 5, 5) {
+			t.Fatalf("expected the changes of 'lib/a.php' for 'src/lib/a.php'")
+		}
+	}
+
+	// With the known root, the paths are matched exactly,
+	// so 'a.php' does not match 'other/a.php'.
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "lib", "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.php"), []byte("<?php\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes.ResolveRoot(filepath.Join(root, "lib", "nested"))
+	if changes.Root != root {
+		t.Fatalf("expected the root %s, got %s", root, changes.Root)
+	}
+
+	if changes.Contains(filepath.Join(root, "other", "a.php"), 1, 1) {
+		t.Errorf("'a.php' of the diff matches 'other/a.php'")
+	}
+	if !changes.Contains(filepath.Join(root, "a.php"), 1, 1) || !changes.Contains(filepath.Join(root, "lib", "a.php"), 5, 5) {
+		t.Errorf("the changed files are not matched with the root")
+	}
+}
@@ -0,0 +1,114 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+const entryPointsPalette = `
+internals:
+  - api internal: api must not call internals directly
+  - allow-internal api internal: ""
+entry points:
+  - entry: ""
+`
+
+const entryPointsCode = `<?php
+/** @color allow-internal */
+function router() { apiHandler(); }
+
+/** @color api */
+function apiHandler() { internalCall(); router(); }
+
+/** @color internal */
+function internalCall() {}
+`
+
+func TestEntryPointsDisabled(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = entryPointsPalette
+	suite.AddFile(entryPointsCode)
+
+	// Every function is called by another one, so the
+	// chain search starts from an arbitrary function.
+	suite.Expect = []string{
+		`
+api internal => api must not call internals directly
+  This color rule is broken, call chain:
+apiHandler@api -> internalCall@internal
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestEntryPoints(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = entryPointsPalette
+	suite.EntryPoints = `
+http:
+  - function: router
+  - attribute: Route
+cli:
+  - file: ./cli
+  - color: entry
+`
+	suite.AddFile(entryPointsCode)
+	suite.AddFile(`<?php
+#[\Attribute]
+class Route {}
+
+class Controller {
+  #[Route]
+  /** @color api */
+  public function action() { internalCall(); }
+}
+
+/** @color entry */
+function job() { jobApi(); }
+
+/** @color api */
+function jobApi() { internalCall(); }
+
+/** @color api */
+function unusedApi() { internalCall(); }
+`)
+	suite.AddNamedFile("cli/run.php", `<?php
+cliApi();
+
+/** @color api */
+function cliApi() { internalCall(); }
+`)
+
+	suite.Expect = []string{
+		`
+api internal => api must not call internals directly
+  This color rule is broken, call chain:
+Controller::action@api -> internalCall@internal
+`,
+		`
+api internal => api must not call internals directly
+  This color rule is broken, call chain:
+jobApi@api -> internalCall@internal
+`,
+		`
+api internal => api must not call internals directly
+  This color rule is broken, call chain:
+cliApi@api -> internalCall@internal
+`,
+	}
+
+	suite.RunAndMatch()
+
+	var unreachable []string
+	for _, fun := range suite.UnreachableFunctions() {
+		unreachable = append(unreachable, fun.HumanReadableName())
+	}
+
+	if len(unreachable) != 1 || unreachable[0] != "unusedApi" {
+		t.Errorf("unexpected unreachable functions: %v", unreachable)
+	}
+}
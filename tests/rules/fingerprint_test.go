@@ -0,0 +1,90 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func fingerprints(t *testing.T, code string) []string {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(code)
+
+	var fingerprints []string
+	for _, report := range suite.RunLinter() {
+		fingerprints = append(fingerprints, report.Fingerprint)
+	}
+
+	return fingerprints
+}
+
+func TestFingerprints(t *testing.T) {
+	direct := fingerprints(t, `<?php
+/** @color api */
+function api() { hasCurl(); api2(); }
+
+/** @color api2 */
+function api2() { hasCurl(); }
+
+/** @color has-curl */
+function hasCurl() {}
+
+api();
+`)
+
+	// The chain changes, but the violations are the same.
+	indirect := fingerprints(t, `<?php
+/** @color api */
+function api() { helper(); api2(); }
+
+function helper() { hasCurl(); }
+
+/** @color api2 */
+function api2() { hasCurl(); }
+
+/** @color has-curl */
+function hasCurl() {}
+
+api();
+`)
+
+	if len(direct) != 2 || direct[0] == direct[1] {
+		t.Fatalf("expected two different fingerprints, got %v", direct)
+	}
+	if len(indirect) != 2 || indirect[0] != direct[0] || indirect[1] != direct[1] {
+		t.Errorf("fingerprints changed with the chain: %v, expected %v", indirect, direct)
+	}
+}
+
+func TestFingerprintsOfAnonClasses(t *testing.T) {
+	const code = `<?php
+/** @color has-curl */
+function hasCurl() {}
+
+$first = new class {
+  /** @color api */
+  public function run() { hasCurl(); }
+};
+$first->run();
+
+$second = new class {
+  /** @color api */
+  public function run() { hasCurl(); }
+};
+$second->run();
+`
+
+	anon := fingerprints(t, code)
+	if len(anon) != 2 || anon[0] == anon[1] {
+		t.Fatalf("expected two different fingerprints of the anonymous classes, got %v", anon)
+	}
+
+	// The code above the classes is changed.
+	shifted := fingerprints(t, strings.Replace(code, "function hasCurl() {}", "function hasCurl() {\n  echo 1;\n}\n\nfunction unused() {}", 1))
+	if len(shifted) != 2 || shifted[0] != anon[0] || shifted[1] != anon[1] {
+		t.Errorf("fingerprints changed with the lines: %v, expected %v", shifted, anon)
+	}
+}
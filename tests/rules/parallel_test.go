@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+// parallelCode returns the code of a graph that is large enough for
+// its roots to be checked in parallel, see pipes.CheckColorsInGraph.
+func parallelCode(handlers int) string {
+	var code strings.Builder
+	code.WriteString(`<?php
+/** @color curl */
+function request() {}
+
+/** @color db */
+function query() {}
+
+/** @color ssr */
+function render() { query(); }
+`)
+
+	for i := 0; i < 20; i++ {
+		if i%3 == 0 {
+			fmt.Fprintf(&code, "/** @color allow-curl */\nfunction service%d() { request(); }\n", i)
+			continue
+		}
+		fmt.Fprintf(&code, "function service%d() { request(); render(); }\n", i)
+	}
+
+	for i := 0; i < handlers; i++ {
+		fmt.Fprintf(&code, "/** @color api */\nfunction handler%d() { helper%d(); }\n", i, i)
+		fmt.Fprintf(&code, "function helper%d() { service%d(); }\n", i, i%20)
+	}
+
+	return code.String()
+}
+
+func TestParallel(t *testing.T) {
+	// The number of workers is limited by GOMAXPROCS.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	check := func(workers int) []string {
+		suite := linttest.NewSuite(t)
+
+		suite.MaxConcurrency = workers
+		suite.Palette = `
+api:
+  - api curl: api must not perform curl requests
+  - api allow-curl curl: ""
+rendering:
+  - ssr db: ssr must not query the database
+`
+		suite.AddFile(parallelCode(600))
+
+		var messages []string
+		for _, report := range suite.RunLinter() {
+			messages = append(messages, report.Message)
+		}
+		return messages
+	}
+
+	expected := check(1)
+	// 13 of 20 services do not allow curl, and the ssr one is reported once.
+	if len(expected) != 600*13/20+1 {
+		t.Fatalf("expected %d reports, got %d", 600*13/20+1, len(expected))
+	}
+
+	for _, workers := range []int{2, 8} {
+		messages := check(workers)
+		if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("reports with %d workers differ from the sequential check:\n%v\nexpected:\n%v", workers, messages, expected)
+		}
+	}
+}
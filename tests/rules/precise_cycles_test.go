@@ -0,0 +1,125 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+const preciseCyclesPalette = `
+performance:
+  - fast slow: potential performance leak
+  - fast slow-allowed slow: ""
+`
+
+const preciseCyclesCode = `<?php
+/** @color fast */
+function handle() { allowSlow(); }
+
+/** @color slow-allowed */
+function allowSlow() { process(); }
+
+function process() { handle(); slowAction(); }
+
+/** @color slow */
+function slowAction() {}
+
+/** @color fast */
+function handleDirectly() { processDirectly(); }
+
+function processDirectly() { slowAction(); handleDirectly(); }
+
+handle();
+handleDirectly();
+`
+
+func TestPreciseCyclesDisabled(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = preciseCyclesPalette
+	suite.AddFile(preciseCyclesCode)
+
+	// The only path from handle to slowAction goes through allowSlow,
+	// but inside the recursive component it is assumed that
+	// everything is reachable from everything.
+	suite.Expect = []string{
+		`
+fast slow => potential performance leak
+  This color rule is broken, call chain:
+handle@fast -> slowAction@slow
+`,
+		`
+fast slow => potential performance leak
+  This color rule is broken, call chain:
+handleDirectly@fast -> processDirectly -> slowAction@slow
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestPreciseCycles(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.PreciseCycles = true
+	suite.Palette = preciseCyclesPalette
+	suite.AddFile(preciseCyclesCode)
+
+	suite.Expect = []string{
+		`
+fast slow => potential performance leak
+  This color rule is broken, call chain:
+handleDirectly@fast -> processDirectly -> slowAction@slow
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+func TestPreciseCyclesContinuationThroughCallstack(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.PreciseCycles = true
+	suite.Palette = `
+api:
+  - api mid curl: api must not perform curl requests through mid
+other:
+  - unused other: ""
+`
+	suite.AddFile(`<?php
+/** @color api */
+function alpha() { middle(); }
+
+/** @color api */
+function beta() { back(); }
+
+/** @color mid */
+function middle() { back(); keep(); }
+
+/** @color other */
+function back() { middle(); request(); }
+
+/** @color other */
+function keep() { request(); }
+
+/** @color curl */
+function request() {}
+`)
+
+	// The continuation from middle found for alpha passes through back,
+	// which is already in the chain of beta, so the other one is reported.
+	suite.Expect = []string{
+		`
+api mid curl => api must not perform curl requests through mid
+  This color rule is broken, call chain:
+alpha@api -> middle@mid -> back -> request@curl
+`,
+		`
+api mid curl => api must not perform curl requests through mid
+  This color rule is broken, call chain:
+beta@api -> back -> middle@mid -> keep -> request@curl
+`,
+	}
+
+	suite.RunAndMatch()
+}
@@ -0,0 +1,202 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+)
+
+func TestScopedRemover(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+rendering:
+  - ssr db: ssr must not query the database
+  - ssr curl: ssr must not perform curl requests
+performance:
+  - fast slow: potential performance leak
+`
+	suite.AddFile(`<?php
+/** @color remover:db */
+function dispatch($action) {
+    if ($action == 1) query();
+    if ($action == 2) request();
+    if ($action == 3) slowAction();
+}
+
+/** @color db */
+function query() {}
+
+/** @color curl */
+function request() {}
+
+/** @color slow */
+function slowAction() {}
+
+/** @color ssr */
+function render() {
+    dispatch(1);
+}
+
+/** @color fast */
+function handle() {
+    dispatch(3);
+}
+
+/** @color remover:rendering */
+function dispatchAll() {
+    query();
+    slowAction();
+}
+
+/** @color ssr */
+function renderAll() {
+    dispatchAll();
+}
+
+/** @color fast */
+function handleAll() {
+    dispatchAll();
+}
+
+/** @color remover:{db,slow} */
+function dispatchNothing() {
+    request();
+}
+
+/** @color ssr */
+function renderNothing() {
+    dispatchNothing();
+}
+`)
+
+	suite.Expect = []string{
+		`
+ssr curl => ssr must not perform curl requests
+  This color rule is broken, call chain:
+render@ssr -> dispatch -> request@curl
+`,
+		`
+fast slow => potential performance leak
+  This color rule is broken, call chain:
+handleAll@fast -> dispatchAll -> slowAction@slow
+`,
+		`
+ssr curl => ssr must not perform curl requests
+  This color rule is broken, call chain:
+renderNothing@ssr -> dispatchNothing -> request@curl
+`,
+		`
+fast slow => potential performance leak
+  This color rule is broken, call chain:
+handle@fast -> dispatch -> slowAction@slow
+`,
+	}
+
+	suite.RunAndMatch()
+}
+
+const scopedRemoverCyclePalette = `
+rendering:
+  - ssr db: ssr must not query the database
+`
+
+// The scoped remover is in the recursive component, but the
+// query is also reachable from it not through the remover.
+const scopedRemoverCycleCode = `<?php
+/** @color remover:db */
+function r() { a(); }
+
+function a() { r(); query(); }
+
+/** @color db */
+function query() {}
+
+/** @color ssr */
+function render() { a(); }
+`
+
+func TestScopedRemoverInCycle(t *testing.T) {
+	for _, precise := range []bool{false, true} {
+		suite := linttest.NewSuite(t)
+
+		suite.PreciseCycles = precise
+		suite.Palette = scopedRemoverCyclePalette
+		suite.AddFile(scopedRemoverCycleCode)
+
+		suite.Expect = []string{
+			`
+ssr db => ssr must not query the database
+  This color rule is broken, call chain:
+render@ssr -> a -> query@db
+`,
+		}
+
+		suite.RunAndMatch()
+	}
+}
+
+func TestScopedRemoverInCyclePrecise(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.PreciseCycles = true
+	suite.Palette = scopedRemoverCyclePalette
+	suite.AddFile(`<?php
+/** @color remover:db */
+function r() { a(); query(); }
+
+function a() { r(); }
+
+/** @color db */
+function query() {}
+
+/** @color ssr */
+function render() { a(); }
+`)
+
+	// The only path from render to query goes through the remover.
+	suite.Expect = []string{}
+
+	suite.RunAndMatch()
+}
+
+func TestScopedRemoverThroughColored(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = `
+performance:
+  - fast db: fast functions must not query the database
+rendering:
+  - ssr db: ssr must not query the database
+logging:
+  - logged curl: logged functions must not perform curl requests
+`
+	suite.AddFile(`<?php
+/** @color remover:db */
+function dispatch() { middleware(); }
+
+/** @color logged */
+function middleware() { query(); }
+
+/** @color db */
+function query() {}
+
+/** @color fast */
+function handle() { dispatch(); }
+
+/** @color ssr */
+function render() { middleware(); }
+`)
+
+	// The query is reachable from handle only through the remover,
+	// even though there is a colored function between them.
+	suite.Expect = []string{
+		`
+ssr db => ssr must not query the database
+  This color rule is broken, call chain:
+render@ssr -> middleware -> query@db
+`,
+	}
+
+	suite.RunAndMatch()
+}
@@ -0,0 +1,113 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vkcom/nocolor/internal/linttest"
+	"github.com/vkcom/nocolor/internal/pipes"
+)
+
+func TestSuppression(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(`<?php
+/** @color highload */
+function handler() { legacy(); }
+
+/**
+ * @nocolor-ignore highload no-highload will be rewritten in TASK-1
+ */
+function legacy() { slow(); }
+
+/** @color no-highload */
+function slow() {}
+
+/** @color ssr */
+function render() { legacy(); query(); }
+
+/** @color has-db-access */
+function query() {}
+
+/** @nocolor-ignore ssr group rendered only in the admin panel */
+class Admin {
+  /** @color ssr */
+  public function page() { query(); }
+}
+
+/** @nocolor-ignore api group nothing to suppress */
+function unused() {}
+
+/** @nocolor-ignore ssr group no methods to suppress */
+class Constants {
+  const PAGE = 1;
+}
+
+handler();
+render();
+(new Admin)->page();
+unused();
+`)
+
+	// Only the violation of the other ruleset through
+	// the function with the tag is left.
+	suite.Expect = []string{
+		`
+ssr has-db-access => Calling function working with the database in the server side rendering function
+  This color rule is broken, call chain:
+render@ssr -> query@has-db-access
+`,
+	}
+
+	suite.RunAndMatch()
+
+	unused := pipes.UnusedSuppressions(suite.Functions(), suite.Classes())
+	if len(unused) != 2 || unused[0].Target != "api group" || unused[0].Place != "unused" || unused[0].Reason != "nothing to suppress" {
+		t.Fatalf("expected the unused tags of the 'unused' function and the 'Constants' class, got %+v", unused)
+	}
+	if unused[1].Target != "ssr group" || unused[1].Place != "Constants" || unused[1].Reason != "no methods to suppress" {
+		t.Errorf("expected the unused tag of the 'Constants' class, got %+v", unused[1])
+	}
+}
+
+func TestSuppressionErrors(t *testing.T) {
+	suite := linttest.NewSuite(t)
+
+	suite.Palette = defaultPalette
+	suite.AddFile(`<?php
+/** @nocolor-ignore highload no-highload */
+function noReason() {}
+
+/** @nocolor-ignore unknown-rule legacy */
+function unknownRule() {}
+
+/** @nocolor-ignore */
+function emptyTag() {}
+`)
+
+	suite.RunLinter()
+
+	expected := []string{
+		"The '@nocolor-ignore highload no-highload' tag must have a reason",
+		"Rule or ruleset 'unknown-rule' missing in palette",
+		"An empty '@nocolor-ignore' tag value",
+	}
+
+	reports := suite.LinterReports()
+	if len(reports) != len(expected) {
+		t.Fatalf("expected %d reports, got %d", len(expected), len(reports))
+	}
+
+	for _, message := range expected {
+		found := false
+		for _, report := range reports {
+			if report.CheckName == "errorColor" && strings.Contains(report.Message, message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no report with the message %q", message)
+		}
+	}
+}
@@ -22,7 +22,7 @@ function f2() { f3(); }
 /**
  * @color highload
  */
-function f3() { f4(); /* error 2 (not displayed until error 1 is fixed) */ }
+function f3() { f4(); /* error 2 */ }
 /**
  * @color no-highload
  */
@@ -36,6 +36,11 @@ f1();
 highload no-highload => Calling no-highload function from highload function
   This color rule is broken, call chain:
 f1@highload -> f2@no-highload
+`,
+		`
+highload no-highload => Calling no-highload function from highload function
+  This color rule is broken, call chain:
+f3@highload -> f4@no-highload
 `,
 	}
 